@@ -1,23 +1,27 @@
 #!/bin/bash
 
-GO_FILE="src/rncs.go"
+GO_PKG="./cmd/rncs"
 BIN_DIR="bin"
+VERSION="${VERSION:-$(git describe --tags --always 2>/dev/null || echo dev)}"
+COMMIT="$(git rev-parse --short HEAD 2>/dev/null || echo unknown)"
+BUILD_DATE="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+LDFLAGS="-X main.version=$VERSION -X main.commit=$COMMIT -X main.buildDate=$BUILD_DATE"
 
 mkdir -p "$BIN_DIR"
 
 echo "Building for Linux (amd64)..."
-GOOS=linux GOARCH=amd64 go build -o "$BIN_DIR/rncs_linux" "$GO_FILE"
+GOOS=linux GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$BIN_DIR/rncs_linux" "$GO_PKG"
 
 echo "Building for Windows (amd64)..."
-GOOS=windows GOARCH=amd64 go build -o "$BIN_DIR/rncs_win.exe" "$GO_FILE"
+GOOS=windows GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$BIN_DIR/rncs_win.exe" "$GO_PKG"
 
 echo "Building for macOS (amd64)..."
-GOOS=darwin GOARCH=amd64 go build -o "$BIN_DIR/rncs_mac" "$GO_FILE"
+GOOS=darwin GOARCH=amd64 go build -ldflags "$LDFLAGS" -o "$BIN_DIR/rncs_mac" "$GO_PKG"
 
 echo "Building for macOS (ARM64)..."
-GOOS=darwin GOARCH=arm64 go build -o "$BIN_DIR/rncs_mac_arm" "$GO_FILE"
+GOOS=darwin GOARCH=arm64 go build -ldflags "$LDFLAGS" -o "$BIN_DIR/rncs_mac_arm" "$GO_PKG"
 
 echo "Building for Linux (ARM)..."
-GOOS=linux GOARCH=arm go build -o "$BIN_DIR/rncs_arm" "$GO_FILE"
+GOOS=linux GOARCH=arm go build -ldflags "$LDFLAGS" -o "$BIN_DIR/rncs_arm" "$GO_PKG"
 
 echo "✅ All builds complete. The binaries are in the $BIN_DIR folder."
@@ -0,0 +1,241 @@
+// Package client es un cliente Go para la API HTTP de rncs (el servidor de
+// cmd/rncs o cualquier servicio que monte rnc.NewHandler).
+//
+// Los errores de la API se devuelven como *APIError, que se compara con
+// errors.Is contra rnc.ErrNotFound, rnc.ErrInvalidRNC, rnc.ErrMalformedRNC
+// y rnc.ErrNotReady; los fallos de red llegan tal como los da http.Client.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yolfry/rncs/rnc"
+)
+
+// Empresa es el registro que devuelve la API.
+type Empresa = rnc.Empresa
+
+// Valores por defecto de las opciones.
+const (
+	defaultRetries    = 2
+	defaultBackoff    = 200 * time.Millisecond
+	maxRetryAfterWait = 10 * time.Second
+)
+
+// Client consulta una instancia de la API. Es seguro usarlo desde varias
+// goroutines.
+type Client struct {
+	base    string // sin barra final
+	hc      *http.Client
+	apiKey  string
+	retries int
+	backoff time.Duration
+}
+
+// Option ajusta NewClient.
+type Option func(*Client)
+
+// WithHTTPClient usa hc en lugar de http.DefaultClient (timeouts, proxy,
+// TLS, transporte instrumentado...).
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.hc = hc }
+}
+
+// WithAPIKey envía key como "Authorization: Bearer <key>", que sirve tanto
+// para --api-keys-file como para el --api-key de las rutas de escritura.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithRetries fija cuántas veces se reintenta una consulta que recibe un
+// 5xx (2 por defecto; 0 desactiva los reintentos). Entre intentos se espera
+// backoff, el doble cada vez, o el Retry-After del servidor si lo envía.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) { c.retries, c.backoff = n, backoff }
+}
+
+// NewClient devuelve un cliente para la API en baseURL, p. ej.
+// "http://localhost:9922" o "https://intranet/internal/rncs".
+func NewClient(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: expected http(s)://host", baseURL)
+	}
+	c := &Client{base: strings.TrimSuffix(u.String(), "/"), hc: http.DefaultClient, retries: defaultRetries, backoff: defaultBackoff}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+/* ---------- Consultas ---------- */
+
+// CheckRNC busca un RNC o cédula (GET /api/checkrnc/{RNC}). Si no existe el
+// error cumple errors.Is(err, rnc.ErrNotFound).
+func (c *Client) CheckRNC(ctx context.Context, id string) (Empresa, error) {
+	var out Empresa
+	err := c.get(ctx, "/api/checkrnc/"+url.PathEscape(id), nil, &out)
+	return out, err
+}
+
+// SearchOptions pagina Search; los ceros dejan los valores del servidor.
+type SearchOptions struct {
+	Limit  int
+	Offset int
+}
+
+// SearchResult es una página de Search y el total de coincidencias.
+type SearchResult struct {
+	Total   int       `json:"total"`
+	Results []Empresa `json:"results"`
+}
+
+// Search busca empresas cuyo nombre contiene q (GET /api/search).
+func (c *Client) Search(ctx context.Context, q string, opts SearchOptions) (SearchResult, error) {
+	params := url.Values{"q": {q}}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		params.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	var out SearchResult
+	err := c.get(ctx, "/api/search", params, &out)
+	return out, err
+}
+
+// Reload pide al servidor que vuelva a leer el padrón (POST /api/reload) y
+// espera a que termine. No se reintenta: una recarga fallida no debe
+// lanzarse dos veces sin que el llamador lo decida.
+func (c *Client) Reload(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodPost, "/api/reload", url.Values{"wait": {"1"}})
+	if err != nil {
+		return err
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return readAPIError(resp)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+/* ---------- Peticiones ---------- */
+
+// newRequest prepara una petición a path, que ya viene escapado.
+func (c *Client) newRequest(ctx context.Context, method, path string, params url.Values) (*http.Request, error) {
+	u := c.base + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// get hace un GET y decodifica la respuesta en out, reintentando los 5xx.
+func (c *Client) get(ctx context.Context, path string, params url.Values, out any) error {
+	wait := c.backoff
+	for attempt := 0; ; attempt++ {
+		req, err := c.newRequest(ctx, http.MethodGet, path, params)
+		if err != nil {
+			return err
+		}
+		resp, err := c.hc.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode < 300 {
+			err := json.NewDecoder(resp.Body).Decode(out)
+			resp.Body.Close()
+			if err != nil {
+				return fmt.Errorf("decoding %s response: %w", path, err)
+			}
+			return nil
+		}
+		apiErr := readAPIError(resp)
+		resp.Body.Close()
+		if resp.StatusCode < 500 || attempt >= c.retries {
+			return apiErr
+		}
+		d := wait
+		if ra := apiErr.RetryAfter; ra > 0 {
+			d = min(ra, maxRetryAfterWait)
+		}
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+/* ---------- Errores ---------- */
+
+// APIError es una respuesta de error de la API.
+type APIError struct {
+	StatusCode int
+	Message    string        // campo "error" del cuerpo JSON
+	RetryAfter time.Duration // cabecera Retry-After, si la hubo
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("rncs API: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("rncs API: %d %s", e.StatusCode, e.Message)
+}
+
+// Is relaciona los códigos de estado con los errores de rnc: 404 con
+// ErrNotFound, 422 con ErrInvalidRNC, 400 por RNC mal formado con
+// ErrMalformedRNC y 503 con ErrNotReady.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case rnc.ErrNotFound:
+		return e.StatusCode == http.StatusNotFound && e.Message == rnc.ErrNotFound.Error()
+	case rnc.ErrInvalidRNC:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case rnc.ErrMalformedRNC:
+		return e.StatusCode == http.StatusBadRequest && e.Message == rnc.ErrMalformedRNC.Error()
+	case rnc.ErrNotReady:
+		return e.StatusCode == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// readAPIError arma un *APIError con el cuerpo y las cabeceras de resp.
+func readAPIError(resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		e.Message = body.Error
+	}
+	if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+		e.RetryAfter = time.Duration(s) * time.Second
+	}
+	return e
+}
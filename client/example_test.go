@@ -0,0 +1,205 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yolfry/rncs/client"
+	"github.com/yolfry/rncs/rnc"
+)
+
+// testCSV es un padrón mínimo con la cabecera de la DGII.
+const testCSV = "RNC,RAZÓN SOCIAL,NOMBRE COMERCIAL,ACTIVIDAD ECONÓMICA,FECHA DE INICIO,ESTADO,RÉGIMEN DE PAGO\n" +
+	"132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n" +
+	"101010632,CONSTRUCTORA DEL CARIBE SRL,CARIBE,CONSTRUCCION,01/01/2000,SUSPENDIDO,NORMAL\n"
+
+// newServer levanta la API real (rnc.NewHandler) sobre testCSV, con opts.
+// Los ejemplos no tienen *testing.T: close borra también el CSV.
+func newServer(opts ...rnc.HandlerOption) (srv *httptest.Server, close func()) {
+	dir, err := os.MkdirTemp("", "rncs-client")
+	if err != nil {
+		log.Fatal(err)
+	}
+	path := filepath.Join(dir, "rncs.csv")
+	if err := os.WriteFile(path, []byte(testCSV), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	idx, err := rnc.NewIndexFromCSV(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv = httptest.NewServer(rnc.NewHandler(idx, opts...))
+	return srv, func() {
+		srv.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func ExampleClient_CheckRNC() {
+	srv, closeSrv := newServer()
+	defer closeSrv()
+
+	c, err := client.NewClient(srv.URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	emp, err := c.CheckRNC(context.Background(), "1-32-13827-9")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(emp.RNC, emp.SocialName, emp.Status)
+
+	_, err = c.CheckRNC(context.Background(), "131000012")
+	fmt.Println(errors.Is(err, rnc.ErrNotFound))
+	// Output:
+	// 132138279 FERRETERIA AMERICANA SRL ACTIVO
+	// true
+}
+
+func ExampleClient_Search() {
+	srv, closeSrv := newServer()
+	defer closeSrv()
+
+	c, err := client.NewClient(srv.URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	res, err := c.Search(context.Background(), "srl", client.SearchOptions{Limit: 1})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(res.Total, len(res.Results), res.Results[0].RNC)
+	// Output:
+	// 2 1 132138279
+}
+
+func ExampleClient_Reload() {
+	srv, closeSrv := newServer()
+	defer closeSrv()
+
+	c, err := client.NewClient(srv.URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(c.Reload(context.Background()))
+	// Output:
+	// <nil>
+}
+
+// TestErrors comprueba que cada error de la API real se reconoce con
+// errors.Is contra el error de rnc que le corresponde, y solo contra ese.
+func TestErrors(t *testing.T) {
+	srv, closeSrv := newServer()
+	defer closeSrv()
+	loading := httptest.NewServer(rnc.NewHandler(nil)) // sin datos: 503
+	defer loading.Close()
+
+	targets := []error{rnc.ErrNotFound, rnc.ErrInvalidRNC, rnc.ErrMalformedRNC, rnc.ErrNotReady}
+	tests := []struct {
+		name string
+		url  string
+		id   string
+		want error
+		code int
+	}{
+		{"no existe", srv.URL, "131000012", rnc.ErrNotFound, 404},
+		{"dígito verificador", srv.URL, "132138278", rnc.ErrInvalidRNC, 422},
+		{"mal formado", srv.URL, "13213827X", rnc.ErrMalformedRNC, 400},
+		{"cargando", loading.URL, "132138279", rnc.ErrNotReady, 503},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := client.NewClient(tt.url, client.WithRetries(0, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.CheckRNC(context.Background(), tt.id)
+			var apiErr *client.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.code {
+				t.Fatalf("err = %v, want an *APIError with status %d", err, tt.code)
+			}
+			for _, target := range targets {
+				if got := errors.Is(err, target); got != (target == tt.want) {
+					t.Errorf("errors.Is(%v, %q) = %v", err, target, got)
+				}
+			}
+		})
+	}
+}
+
+// TestRetries comprueba que los 5xx se reintentan y los 4xx no.
+func TestRetries(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rncs.csv")
+	if err := os.WriteFile(path, []byte(testCSV), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := rnc.NewIndexFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls, failures atomic.Int32
+	srv := httptest.NewServer(rnc.NewHandler(nil, rnc.WithStoreFunc(func(context.Context) (rnc.Store, error) {
+		calls.Add(1)
+		if failures.Add(-1) >= 0 {
+			return nil, errors.New("disk on fire") // 500
+		}
+		return idx, nil
+	})))
+	defer srv.Close()
+
+	c, err := client.NewClient(srv.URL, client.WithRetries(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	failures.Store(2)
+	if _, err := c.CheckRNC(ctx, "132138279"); err != nil || calls.Load() != 3 {
+		t.Errorf("two 500s then 200: err = %v after %d calls, want nil after 3", err, calls.Load())
+	}
+
+	calls.Store(0)
+	failures.Store(3)
+	if _, err := c.CheckRNC(ctx, "132138279"); err == nil || calls.Load() != 3 {
+		t.Errorf("three 500s: err = %v after %d calls, want an error after 3", err, calls.Load())
+	}
+
+	calls.Store(0)
+	failures.Store(0)
+	if _, err := c.CheckRNC(ctx, "131000012"); !errors.Is(err, rnc.ErrNotFound) || calls.Load() != 1 {
+		t.Errorf("404: err = %v after %d calls, want ErrNotFound after 1", err, calls.Load())
+	}
+}
+
+// TestTransportError comprueba que un fallo de red no se confunde con una
+// respuesta de la API y que una URL base inválida se rechaza.
+func TestTransportError(t *testing.T) {
+	srv, closeSrv := newServer()
+	url := srv.URL
+	closeSrv()
+
+	c, err := client.NewClient(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.CheckRNC(context.Background(), "132138279")
+	var apiErr *client.APIError
+	if err == nil || errors.As(err, &apiErr) || errors.Is(err, rnc.ErrNotFound) {
+		t.Errorf("closed server: err = %v, want a transport error", err)
+	}
+
+	for _, base := range []string{"localhost:9922", "ftp://localhost", "http://"} {
+		if _, err := client.NewClient(base); err == nil {
+			t.Errorf("NewClient(%q) accepted", base)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/yolfry/rncs/rnc"
+)
+
+/* ---------- API keys ---------- */
+
+// apiKey es una entrada de --api-keys-file. id es lo único que se registra
+// en los logs.
+type apiKey struct {
+	id  string
+	key []byte
+}
+
+// apiKeys son las claves vigentes; nil si no se usa --api-keys-file.
+var apiKeys atomic.Pointer[[]apiKey]
+
+// loadAPIKeys lee una clave por línea, opcionalmente como "id:clave". Las
+// líneas vacías y las que empiezan por # se ignoran. Sin id explícito se usa
+// el prefijo del SHA-256 de la clave. Un id o una clave vacíos son un error:
+// una clave vacía dejaría pasar las peticiones que no envían ninguna.
+func loadAPIKeys(path string) ([]apiKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []apiKey
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, key, ok := strings.Cut(line, ":")
+		if !ok {
+			sum := sha256.Sum256([]byte(line))
+			id, key = hex.EncodeToString(sum[:4]), line
+		}
+		id, key = strings.TrimSpace(id), strings.TrimSpace(key)
+		switch {
+		case id == "":
+			return nil, fmt.Errorf("%s:%d: empty key id", path, n)
+		case key == "":
+			return nil, fmt.Errorf("%s:%d: empty API key", path, n)
+		}
+		keys = append(keys, apiKey{id: id, key: []byte(key)})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s has no API keys", path)
+	}
+	return keys, nil
+}
+
+// reloadAPIKeys vuelve a leer --api-keys-file; si falla se mantienen las
+// claves anteriores.
+func reloadAPIKeys() error {
+	keys, err := loadAPIKeys(apiKeysFile)
+	if err != nil {
+		return err
+	}
+	apiKeys.Store(&keys)
+	slog.Info("API keys loaded", "path", apiKeysFile, "keys", len(keys))
+	return nil
+}
+
+// requestKey devuelve la clave enviada en X-Api-Key o como Bearer.
+func requestKey(r *http.Request) string {
+	if k := r.Header.Get("X-Api-Key"); k != "" {
+		return k
+	}
+	k, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return k
+}
+
+// matchKey busca key entre las claves vigentes comparando en tiempo constante.
+// Una clave vacía nunca coincide.
+func matchKey(keys []apiKey, key string) (id string, ok bool) {
+	if key == "" {
+		return "", false
+	}
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare(k.key, []byte(key)) == 1 {
+			id, ok = k.id, true
+		}
+	}
+	return id, ok
+}
+
+// requireAPIKey exige una clave válida en las rutas /api/* cuando hay
+// --api-keys-file. El id de la clave queda en el log de la petición.
+func requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := apiKeys.Load()
+		if keys == nil || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		id, ok := matchKey(*keys, requestKey(r))
+		if !ok {
+			rnc.WriteError(w, http.StatusUnauthorized, "Missing or invalid API key")
+			return
+		}
+		setKeyID(r, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+/* ---------- Clave de escritura ---------- */
+
+// writeAllowed indica si r puede usar un endpoint de escritura: sin --api-key
+// ni tokens propios del endpoint siempre; si no, el Bearer debe coincidir con
+// alguno de ellos.
+func writeAllowed(r *http.Request, tokens ...string) bool {
+	open := true
+	for _, t := range append(tokens, writeKey) {
+		if t == "" {
+			continue
+		}
+		if validBearer(r, t) {
+			return true
+		}
+		open = false
+	}
+	return open
+}
+
+// unauthorized responde 401 pidiendo un token Bearer.
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	rnc.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+}
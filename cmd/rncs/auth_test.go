@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAPIKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		ids     []string
+		wantErr string // subcadena del error; "" si debe cargar
+	}{
+		{"id y clave", "partner:s3cret\n", []string{"partner"}, ""},
+		{"comentarios y vacías", "# claves\n\nalpha:a1\n  beta : b2  \n", []string{"alpha", "beta"}, ""},
+		{"sin id", "s3cret\n", []string{"1ec1c26b"}, ""},
+		{"clave vacía", "alpha:a1\npartner:\n", nil, ":2: empty API key"},
+		{"clave en blanco", "partner:   \n", nil, ":1: empty API key"},
+		{"id vacío", ":s3cret\n", nil, ":1: empty key id"},
+		{"sin claves", "# nada\n", nil, "has no API keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			keys, err := loadAPIKeys(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, k := range keys {
+				ids = append(ids, k.id)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.ids, ",") {
+				t.Errorf("ids = %v, want %v", ids, tt.ids)
+			}
+		})
+	}
+}
+
+func TestMatchKeyEmpty(t *testing.T) {
+	// aunque una clave vacía se colara en la lista, una petición sin clave
+	// no debe pasar
+	keys := []apiKey{{id: "partner", key: []byte{}}, {id: "alpha", key: []byte("a1")}}
+	if id, ok := matchKey(keys, ""); ok {
+		t.Fatalf("empty key matched %q", id)
+	}
+	if id, ok := matchKey(keys, "a1"); !ok || id != "alpha" {
+		t.Fatalf("matchKey(a1) = %q, %v", id, ok)
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	keys := []apiKey{{id: "partner", key: []byte("s3cret")}}
+	apiKeys.Store(&keys)
+	t.Cleanup(func() { apiKeys.Store(nil) })
+	h := requireAPIKey(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name, path, header, value string
+		code                      int
+	}{
+		{"sin clave", "/api/checkrnc/132138279", "", "", http.StatusUnauthorized},
+		{"clave errónea", "/api/checkrnc/132138279", "X-Api-Key", "nope", http.StatusUnauthorized},
+		{"X-Api-Key", "/api/checkrnc/132138279", "X-Api-Key", "s3cret", http.StatusOK},
+		{"Bearer", "/api/checkrnc/132138279", "Authorization", "Bearer s3cret", http.StatusOK},
+		{"fuera de /api/", "/healthz", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.code {
+				t.Errorf("%s = %d, want %d", tt.path, rec.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestWriteAllowed(t *testing.T) {
+	orig := writeKey
+	t.Cleanup(func() { writeKey = orig })
+
+	tests := []struct {
+		name, apiKey, token, bearer string
+		want                        bool
+	}{
+		{"sin claves", "", "", "", true},
+		{"--api-key correcta", "k1", "", "k1", true},
+		{"--api-key sin Bearer", "k1", "", "", false},
+		{"--api-key errónea", "k1", "", "k2", false},
+		{"token del endpoint", "k1", "t1", "t1", true},
+		{"solo token del endpoint", "", "t1", "k1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeKey = tt.apiKey
+			r := httptest.NewRequest(http.MethodPost, "/api/reload", nil)
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if got := writeAllowed(r, tt.token); got != tt.want {
+				t.Errorf("writeAllowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	writeKey = "k1"
+	if code, _ := do(t, http.MethodPost, "/api/reload", ""); code != http.StatusUnauthorized {
+		t.Errorf("POST /api/reload without the key = %d, want 401", code)
+	}
+	if code, _ := get(t, "/api/validate/132138279"); code != http.StatusOK {
+		t.Errorf("GET with --api-key set = %d, want 200 (reads stay open)", code)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+/* ---------- Circuit breaker ---------- */
+
+// Estados de breakerState.State.
+const (
+	breakerClosed   = "closed"    // las llamadas pasan
+	breakerOpen     = "open"      // se rechazan sin llamar hasta que pase el cooldown
+	breakerHalfOpen = "half-open" // cooldown cumplido: la próxima llamada decide
+)
+
+// probeRetryAfter es la espera que se sugiere a quien llega mientras la
+// llamada de prueba del estado half-open sigue en curso.
+const probeRetryAfter = time.Second
+
+// breaker deja de llamar a un servicio externo durante cooldown tras
+// threshold fallos seguidos. Pasado el cooldown deja pasar una sola llamada
+// de prueba y rechaza las demás hasta que se resuelva: un éxito lo cierra y
+// un fallo lo vuelve a abrir. threshold <= 0 lo desactiva.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int // fallos seguidos
+	openUntil time.Time
+	probing   bool // hay una llamada de prueba en curso
+}
+
+// breakerState es el estado de un breaker tal como lo muestra /api/status.
+type breakerState struct {
+	State     string     `json:"state"`
+	Failures  int        `json:"failures"`
+	OpenUntil *time.Time `json:"openUntil,omitempty"`
+}
+
+// allow indica si se puede llamar al servicio y, si no, cuánto falta para
+// volver a intentarlo. En half-open solo admite la llamada de prueba; quien
+// la recibe debe terminar con record o abandon.
+func (b *breaker) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return false, wait
+	}
+	if b.threshold > 0 && b.failures >= b.threshold {
+		if b.probing {
+			return false, probeRetryAfter
+		}
+		b.probing = true
+	}
+	return true, 0
+}
+
+// record anota el resultado de una llamada.
+func (b *breaker) record(ok bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if ok {
+		b.failures, b.openUntil = 0, time.Time{}
+		return
+	}
+	if b.failures++; b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// abandon libera la llamada de prueba que no llegó a dar un resultado (el
+// cliente se fue), para que la siguiente pueda probar.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) state() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := breakerState{State: breakerClosed, Failures: b.failures}
+	switch {
+	case time.Now().Before(b.openUntil):
+		st.State = breakerOpen
+		until := b.openUntil
+		st.OpenUntil = &until
+	case b.threshold > 0 && b.failures >= b.threshold:
+		st.State = breakerHalfOpen
+	}
+	return st
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBreakerHalfOpen comprueba que, pasado el cooldown, entra una sola
+// llamada de prueba y que su resultado decide el estado.
+func TestBreakerHalfOpen(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	tests := []struct {
+		name   string
+		finish func(*breaker)
+		state  string
+		allow  bool // ¿pasa la llamada siguiente?
+	}{
+		{"la prueba tiene éxito", func(b *breaker) { b.record(true) }, breakerClosed, true},
+		{"la prueba falla", func(b *breaker) { b.record(false) }, breakerOpen, false},
+		{"la prueba se abandona", (*breaker).abandon, breakerHalfOpen, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &breaker{threshold: 2, cooldown: cooldown}
+			b.record(false)
+			b.record(false)
+			if ok, wait := b.allow(); ok || wait <= 0 {
+				t.Fatalf("open: allow() = %v, %v; want false and a wait", ok, wait)
+			}
+
+			time.Sleep(cooldown)
+			if got := b.state().State; got != breakerHalfOpen {
+				t.Fatalf("after cooldown: state = %q, want %q", got, breakerHalfOpen)
+			}
+			if ok, _ := b.allow(); !ok {
+				t.Fatal("half-open: the probe was rejected")
+			}
+			for range 3 {
+				if ok, wait := b.allow(); ok || wait != probeRetryAfter {
+					t.Fatalf("probe in flight: allow() = %v, %v; want false, %v", ok, wait, probeRetryAfter)
+				}
+			}
+
+			tt.finish(b)
+			if got := b.state().State; got != tt.state {
+				t.Errorf("state = %q, want %q", got, tt.state)
+			}
+			if ok, _ := b.allow(); ok != tt.allow {
+				t.Errorf("next allow() = %v, want %v", ok, tt.allow)
+			}
+		})
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := &breaker{cooldown: time.Hour}
+	for range 10 {
+		b.record(false)
+	}
+	for range 3 {
+		if ok, _ := b.allow(); !ok {
+			t.Fatal("threshold 0: allow() = false")
+		}
+	}
+	if got := b.state().State; got != breakerClosed {
+		t.Errorf("state = %q, want %q", got, breakerClosed)
+	}
+}
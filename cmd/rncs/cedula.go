@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yolfry/rncs/rnc"
+)
+
+/* ---------- Cédulas ---------- */
+
+const cedulaAPI = "https://api.digital.gob.do/v3/cedulas/%s/validate"
+
+// Valores de --cedula-mode.
+const (
+	cedulaAuto   = "auto"   // API externa; validación local si falla o el número está mal formado
+	cedulaLocal  = "local"  // solo validación local
+	cedulaRemote = "remote" // solo la API externa, sin validación previa ni respaldo
+)
+
+// Valores de cedulaCheck.Source.
+const (
+	sourceLocal    = "local"          // modo local o número mal formado
+	sourceDegraded = "degraded"       // la API externa falló y se respondió con la validación local
+	sourceRemote   = "digital.gob.do" // respuesta de la API externa
+)
+
+// cedulaCheck es la respuesta de /api/checkcedula/, venga de la validación
+// local o de la API externa: la cédula normalizada, si es válida y el origen
+// de la respuesta.
+type cedulaCheck struct {
+	Error  string `json:"error,omitempty"` // solo en 422
+	Cedula string `json:"cedula"`
+	rnc.Validation
+	Source string `json:"source"`
+}
+
+// checkCedula atiende GET /api/checkcedula/{CEDULA} según --cedula-mode. En
+// auto, las cédulas mal formadas se rechazan con 422 sin llamar a la API
+// externa y, si esta falla, se responde con la validación local marcada
+// como "degraded" en lugar de un 502. Las respuestas de la API externa se
+// traducen a cedulaCheck o a rnc.ErrorResponse (ver normalizarCedula); con ?raw=1 se
+// reenvían tal cual.
+func checkCedula(w http.ResponseWriter, r *http.Request) {
+	if !rnc.AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	cedula, err := rnc.PathParam(r, "/api/checkcedula/", "Cedula")
+	if err != nil {
+		rnc.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	norm, digits := rnc.Normalize(cedula)
+	local := rnc.Validate(norm)
+	if digits && len(norm) != 11 { // un RNC válido tampoco es una cédula
+		local = rnc.Validation{Reason: "wrong length, expected 11 digits"}
+	}
+	if !digits || (cedulaMode != cedulaRemote && !local.Valid) {
+		rnc.WriteJSON(w, http.StatusUnprocessableEntity, cedulaCheck{Error: "invalid cedula format", Cedula: norm, Validation: local, Source: sourceLocal})
+		return
+	}
+	if cedulaMode == cedulaLocal {
+		rnc.WriteJSON(w, http.StatusOK, cedulaCheck{Cedula: norm, Validation: local, Source: sourceLocal})
+		return
+	}
+
+	res, ok := cedulaCache.Get(norm)
+	countCedulaCache(ok)
+	if ok {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+		if open, wait := cedulaBreaker.allow(); !open {
+			if cedulaMode == cedulaAuto {
+				rnc.WriteJSON(w, http.StatusOK, cedulaCheck{Cedula: norm, Validation: local, Source: sourceDegraded})
+				return
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			rnc.WriteError(w, http.StatusServiceUnavailable, "External API unavailable, try again later")
+			return
+		}
+		var err error
+		res, err = consultarCedula(r.Context(), norm)
+		if r.Context().Err() == nil {
+			cedulaBreaker.record(err == nil && res.status < 500)
+		} else { // un cliente que se va no es un fallo del servicio
+			cedulaBreaker.abandon()
+		}
+		if err != nil {
+			if cedulaMode == cedulaAuto && r.Context().Err() == nil {
+				slog.Warn("External cedula API failed, answering with local validation", "err", err)
+				rnc.WriteJSON(w, http.StatusOK, cedulaCheck{Cedula: norm, Validation: local, Source: sourceDegraded})
+				return
+			}
+			if isTimeout(err) {
+				rnc.WriteError(w, http.StatusGatewayTimeout, "External API timed out")
+				return
+			}
+			rnc.WriteError(w, http.StatusBadGateway, "Error contacting external API")
+			return
+		}
+		if cacheableCedula(res.status) {
+			cedulaCache.Add(norm, res)
+		} else if res.status >= 500 && cedulaMode == cedulaAuto {
+			slog.Warn("External cedula API failed, answering with local validation", "status", res.status)
+			rnc.WriteJSON(w, http.StatusOK, cedulaCheck{Cedula: norm, Validation: local, Source: sourceDegraded})
+			return
+		}
+	}
+	if r.URL.Query().Get("raw") == "1" {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(res.status)
+		_, _ = w.Write(res.body)
+		return
+	}
+	status, out := normalizarCedula(norm, res)
+	rnc.WriteJSON(w, status, out)
+}
+
+// normalizarCedula traduce una respuesta de la API externa al esquema local:
+// cedulaCheck si trae un "valid" booleano con 200, y si no rnc.ErrorResponse con el
+// mismo código (404, 422, 429) o 502 para los 5xx y respuestas que no se
+// entienden. Los demás campos de la API externa se descartan.
+func normalizarCedula(cedula string, res cedulaResult) (int, any) {
+	var up struct {
+		Valid *bool `json:"valid"`
+	}
+	parsed := json.Unmarshal(res.body, &up) == nil
+	switch {
+	case res.status == http.StatusOK && parsed && up.Valid != nil:
+		return http.StatusOK, cedulaCheck{Cedula: cedula, Validation: rnc.Validation{Valid: *up.Valid}, Source: sourceRemote}
+	case res.status == http.StatusNotFound:
+		return res.status, rnc.ErrorResponse{Error: "Cedula not found"}
+	case res.status == http.StatusUnprocessableEntity:
+		return res.status, rnc.ErrorResponse{Error: "Invalid cedula"}
+	case res.status == http.StatusTooManyRequests:
+		return res.status, rnc.ErrorResponse{Error: "External API rate limit exceeded, try again later"}
+	case res.status == http.StatusOK || res.status >= 500:
+		return http.StatusBadGateway, rnc.ErrorResponse{Error: "Unexpected response from external API"}
+	}
+	return res.status, rnc.ErrorResponse{Error: fmt.Sprintf("External API error (status %d)", res.status)}
+}
+
+// cedulaResult es una respuesta de la API externa tal como llega; se guarda
+// así en caché para poder servir también ?raw=1.
+type cedulaResult struct {
+	status int
+	body   []byte
+}
+
+// cacheableCedula indica si una respuesta de la API externa es definitiva y
+// puede guardarse: 200 o cédula inexistente (404, 422). Los 5xx y los 429
+// son pasajeros.
+func cacheableCedula(status int) bool {
+	return status == http.StatusOK || status == http.StatusNotFound || status == http.StatusUnprocessableEntity
+}
+
+// cedulaClient es el cliente de la API de cédulas, con --cedula-timeout.
+var cedulaClient *http.Client
+
+// cedulaBreaker corta las llamadas a la API externa tras
+// --cedula-breaker-failures fallos seguidos, durante --cedula-breaker-cooldown.
+var cedulaBreaker *breaker
+
+// cedulaCache guarda las respuestas de la API externa por cédula; se
+// configura en init con --cedula-cache-size y --cedula-cache-ttl.
+var cedulaCache *lruCache[string, cedulaResult]
+
+// maxCedulaBody limita lo que se lee (y se guarda en caché) de cada respuesta.
+const maxCedulaBody = 64 << 10
+
+// cedulaRetryDelay es la espera antes de repetir una llamada fallida.
+const cedulaRetryDelay = 200 * time.Millisecond
+
+// consultarCedula llama a la API externa y, ante un fallo pasajero (error de
+// red o 5xx), lo intenta una vez más. Se detiene si ctx termina (el cliente
+// se desconectó).
+func consultarCedula(ctx context.Context, cedula string) (cedulaResult, error) {
+	res, err := pedirCedula(ctx, cedula)
+	if err == nil && res.status < 500 {
+		return res, nil
+	}
+	select {
+	case <-ctx.Done():
+		return res, err
+	case <-time.After(cedulaRetryDelay):
+	}
+	return pedirCedula(ctx, cedula)
+}
+
+// pedirCedula hace una llamada a la API externa, sin exceder
+// --cedula-timeout ni la vida de ctx.
+func pedirCedula(ctx context.Context, cedula string) (cedulaResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, cedulaTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(cedulaAPI, cedula), nil)
+	if err != nil {
+		return cedulaResult{}, err
+	}
+	resp, err := cedulaClient.Do(req)
+	if err != nil {
+		return cedulaResult{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCedulaBody))
+	if err != nil {
+		return cedulaResult{}, err
+	}
+	return cedulaResult{status: resp.StatusCode, body: body}, nil
+}
+
+// isTimeout indica si err se debe a un timeout o a la cancelación del contexto.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) ||
+		(errors.As(err, &ne) && ne.Timeout())
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCheckCedulaLocal cubre los casos que se resuelven sin llamar a la API
+// externa: cédulas mal formadas y --cedula-mode=local.
+func TestCheckCedulaLocal(t *testing.T) {
+	cedulaMode = cedulaLocal
+	t.Cleanup(func() { cedulaMode = cedulaAuto })
+
+	tests := []struct {
+		cedula string
+		code   int
+	}{
+		{"001-1391820-5", http.StatusOK},
+		{"40200000004", http.StatusOK},
+		{"00113918206", http.StatusUnprocessableEntity}, // dígito verificador
+		{"0011391820", http.StatusUnprocessableEntity},  // 10 dígitos
+		{"132138279", http.StatusUnprocessableEntity},   // es un RNC
+		{"", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		code, body := get(t, "/api/checkcedula/"+tt.cedula)
+		if code != tt.code {
+			t.Errorf("%q = %d %s, want %d", tt.cedula, code, body, tt.code)
+		}
+		if code == http.StatusOK && !strings.Contains(body, `"valid":true,"type":"cedula","source":"local"`) {
+			t.Errorf("%q: body %s, want valid", tt.cedula, body)
+		}
+	}
+}
+
+// roundTripFunc permite simular la API externa de cédulas.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// stubCedulaAPI hace que las llamadas a la API externa las responda rt, con
+// un caché y un circuit breaker nuevos; al terminar el test restaura todo.
+func stubCedulaAPI(t *testing.T, rt roundTripFunc) {
+	t.Helper()
+	origCache, origBreaker, orig := cedulaCache, cedulaBreaker, cedulaClient
+	cedulaCache = newLRUCache[string, cedulaResult](10, time.Hour)
+	cedulaBreaker = &breaker{threshold: breakerFailures, cooldown: breakerCooldown}
+	cedulaClient = &http.Client{Transport: rt}
+	t.Cleanup(func() { cedulaCache, cedulaBreaker, cedulaClient = origCache, origBreaker, orig })
+}
+
+// upstreamStatus responde siempre status y cuenta las llamadas en calls.
+func upstreamStatus(status int, calls *int) roundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		*calls++
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(`{"valid":true}`)), Request: r}, nil
+	}
+}
+
+// TestCheckCedulaCache comprueba que solo se guardan en caché las respuestas
+// definitivas de la API externa (con --cedula-mode=remote, sin respaldo) y
+// que X-Cache indica si la respuesta salió del caché. Los 5xx se reintentan
+// una vez y se responden como 502.
+func TestCheckCedulaCache(t *testing.T) {
+	cedulaMode = cedulaRemote
+	t.Cleanup(func() { cedulaMode = cedulaAuto })
+
+	tests := []struct {
+		status int
+		want   int // código de la respuesta normalizada
+		cached bool
+		calls  int // llamadas a la API externa en dos peticiones
+	}{
+		{http.StatusOK, http.StatusOK, true, 1},
+		{http.StatusNotFound, http.StatusNotFound, true, 1},
+		{http.StatusUnprocessableEntity, http.StatusUnprocessableEntity, true, 1},
+		{http.StatusTooManyRequests, http.StatusTooManyRequests, false, 2},
+		{http.StatusServiceUnavailable, http.StatusBadGateway, false, 4},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			calls := 0
+			stubCedulaAPI(t, upstreamStatus(tt.status, &calls))
+			var xcache []string
+			for range 2 {
+				rec := httptest.NewRecorder()
+				newHTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/checkcedula/00113918205", nil))
+				if rec.Code != tt.want {
+					t.Errorf("status %d, want %d", rec.Code, tt.want)
+				}
+				xcache = append(xcache, rec.Header().Get("X-Cache"))
+			}
+			wantX := "MISS MISS"
+			if tt.cached {
+				wantX = "MISS HIT"
+			}
+			if calls != tt.calls || strings.Join(xcache, " ") != wantX {
+				t.Errorf("%d upstream calls, X-Cache %v; want %d and %s", calls, xcache, tt.calls, wantX)
+			}
+		})
+	}
+}
+
+// TestCheckCedulaTimeout comprueba que si la API externa no responde a
+// tiempo se devuelve 504 (con --cedula-mode=remote, sin respaldo).
+func TestCheckCedulaTimeout(t *testing.T) {
+	cedulaMode = cedulaRemote
+	t.Cleanup(func() { cedulaMode = cedulaAuto })
+	origTimeout := cedulaTimeout
+	cedulaTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { cedulaTimeout = origTimeout })
+	stubCedulaAPI(t, func(r *http.Request) (*http.Response, error) {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	})
+
+	if code, body := get(t, "/api/checkcedula/00113918205"); code != http.StatusGatewayTimeout {
+		t.Errorf("slow upstream = %d %s, want 504", code, body)
+	}
+}
+
+// TestCheckCedulaDegraded comprueba que en modo auto un fallo de la API
+// externa se responde con la validación local marcada como "degraded".
+func TestCheckCedulaDegraded(t *testing.T) {
+	origTimeout := cedulaTimeout
+	cedulaTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { cedulaTimeout = origTimeout })
+
+	tests := []struct {
+		name     string
+		upstream roundTripFunc
+		want     string
+	}{
+		{"503", func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("down")), Request: r}, nil
+		}, `{"cedula":"00113918205","valid":true,"type":"cedula","source":"degraded"}`},
+		{"error de red", func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}, `{"cedula":"00113918205","valid":true,"type":"cedula","source":"degraded"}`},
+		{"timeout", func(r *http.Request) (*http.Response, error) {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}, `{"cedula":"00113918205","valid":true,"type":"cedula","source":"degraded"}`},
+		{"200", func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"valid":true}`)), Request: r}, nil
+		}, `{"cedula":"00113918205","valid":true,"source":"digital.gob.do"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubCedulaAPI(t, tt.upstream)
+			if code, body := get(t, "/api/checkcedula/00113918205"); code != http.StatusOK || strings.TrimSpace(body) != tt.want {
+				t.Errorf("= %d %s, want 200 %s", code, body, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckCedulaNormalize comprueba que las respuestas de la API externa se
+// traducen al esquema local, sin campos de más, y que ?raw=1 las reenvía tal
+// cual (con --cedula-mode=remote, sin respaldo).
+func TestCheckCedulaNormalize(t *testing.T) {
+	cedulaMode = cedulaRemote
+	t.Cleanup(func() { cedulaMode = cedulaAuto })
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		wantCode int
+		want     string
+	}{
+		{"válida", http.StatusOK, `{"valid":true,"extra":"x"}`, http.StatusOK, `{"cedula":"00113918205","valid":true,"source":"digital.gob.do"}`},
+		{"no válida", http.StatusOK, `{"valid":false}`, http.StatusOK, `{"cedula":"00113918205","valid":false,"source":"digital.gob.do"}`},
+		{"sin valid", http.StatusOK, `{"ok":true}`, http.StatusBadGateway, `{"error":"Unexpected response from external API"}`},
+		{"no es JSON", http.StatusOK, `<html>`, http.StatusBadGateway, `{"error":"Unexpected response from external API"}`},
+		{"404", http.StatusNotFound, `{"message":"no"}`, http.StatusNotFound, `{"error":"Cedula not found"}`},
+		{"otro 4xx", http.StatusForbidden, `{}`, http.StatusForbidden, `{"error":"External API error (status 403)"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubCedulaAPI(t, func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body)), Request: r}, nil
+			})
+			if code, body := get(t, "/api/checkcedula/001-1391820-5"); code != tt.wantCode || strings.TrimSpace(body) != tt.want {
+				t.Errorf("= %d %s, want %d %s", code, body, tt.wantCode, tt.want)
+			}
+			// la segunda petición sale del caché si la respuesta es definitiva
+			if code, body := get(t, "/api/checkcedula/00113918205?raw=1"); code != tt.status || body != tt.body {
+				t.Errorf("?raw=1 = %d %s, want %d %s", code, body, tt.status, tt.body)
+			}
+		})
+	}
+}
+
+// TestCheckCedulaBreaker comprueba que tras --cedula-breaker-failures fallos
+// seguidos no se llama a la API externa: en auto se responde degradado y en
+// remote 503 con Retry-After.
+func TestCheckCedulaBreaker(t *testing.T) {
+	calls := 0
+	stubCedulaAPI(t, upstreamStatus(http.StatusServiceUnavailable, &calls))
+	cedulaBreaker = &breaker{threshold: 2, cooldown: time.Hour}
+	t.Cleanup(func() { cedulaMode = cedulaAuto })
+
+	for range 2 {
+		get(t, "/api/checkcedula/00113918205")
+	}
+	if calls != 4 {
+		t.Fatalf("%d upstream calls before opening, want 4 (2 requests, 1 retry each)", calls)
+	}
+	if code, body := get(t, "/api/checkcedula/00113918205"); code != http.StatusOK || !strings.Contains(body, `"source":"degraded"`) {
+		t.Errorf("auto with the breaker open = %d %s, want a degraded answer", code, body)
+	}
+	cedulaMode = cedulaRemote
+	rec := httptest.NewRecorder()
+	newHTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/checkcedula/00113918205", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("remote with the breaker open = %d, Retry-After %q; want 503 with Retry-After", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if calls != 4 {
+		t.Errorf("%d upstream calls, want none while open", calls-4)
+	}
+}
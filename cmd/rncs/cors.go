@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/* ---------- CORS ---------- */
+
+// corsAllowed son los orígenes de --cors-origins; nil desactiva CORS.
+var corsAllowed []string
+
+// parseCORSOrigins interpreta --cors-origins: lista separada por comas, "*"
+// para cualquier origen y "" o "none" para desactivar CORS.
+func parseCORSOrigins(s string) []string {
+	if s == "none" {
+		return nil
+	}
+	var out []string
+	for _, o := range strings.Split(s, ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// allowOrigin devuelve el valor de Access-Control-Allow-Origin para origin,
+// o "" si no está permitido.
+func allowOrigin(origin string) string {
+	if slices.Contains(corsAllowed, "*") {
+		return "*"
+	}
+	if origin != "" && slices.Contains(corsAllowed, origin) {
+		return origin
+	}
+	return ""
+}
+
+// cors añade las cabeceras CORS según --cors-origins y responde los
+// preflight OPTIONS con 204 sin llegar a los handlers.
+func cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if corsAllowed != nil {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if ao := allowOrigin(r.Header.Get("Origin")); ao != "" {
+				h.Set("Access-Control-Allow-Origin", ao)
+				if r.Method == http.MethodOptions {
+					h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+					h.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Api-Key")
+					if corsMaxAge > 0 {
+						h.Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+					}
+				}
+			}
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
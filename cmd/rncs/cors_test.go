@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCORSPreflight comprueba que un preflight, aun a una ruta que no
+// existe, responde 204 con Access-Control-Max-Age sin llegar a los handlers.
+func TestCORSPreflight(t *testing.T) {
+	corsAllowed = parseCORSOrigins("https://a.example")
+	t.Cleanup(func() { corsAllowed = parseCORSOrigins(corsOrigins) })
+
+	req := httptest.NewRequest(http.MethodOptions, "/no/existe", nil)
+	req.Header.Set("Origin", "https://a.example")
+	rec := httptest.NewRecorder()
+	newHTTPHandler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("preflight = %d, want 204", rec.Code)
+	}
+	h := rec.Header()
+	if got := h.Get("Access-Control-Allow-Origin"); got != "https://a.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want https://a.example", got)
+	}
+	if got := h.Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Access-Control-Max-Age = %q, want 600 (--cors-max-age default)", got)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name      string
+		origins   string // --cors-origins
+		origin    string // cabecera Origin de la petición
+		method    string
+		wantAllow string // Access-Control-Allow-Origin esperado; "" = ninguno
+		wantCode  int
+	}{
+		{"comodín", "*", "https://a.example", http.MethodGet, "*", http.StatusOK},
+		{"comodín sin Origin", "*", "", http.MethodGet, "*", http.StatusOK},
+		{"permitido", "https://a.example, https://b.example/", "https://b.example", http.MethodGet, "https://b.example", http.StatusOK},
+		{"no permitido", "https://a.example", "https://evil.example", http.MethodGet, "", http.StatusOK},
+		{"preflight permitido", "https://a.example", "https://a.example", http.MethodOptions, "https://a.example", http.StatusNoContent},
+		{"preflight no permitido", "https://a.example", "https://evil.example", http.MethodOptions, "", http.StatusNoContent},
+		{"desactivado", "none", "https://a.example", http.MethodGet, "", http.StatusOK},
+		{"desactivado preflight", "none", "https://a.example", http.MethodOptions, "", http.StatusNoContent},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corsAllowed = parseCORSOrigins(tt.origins)
+			t.Cleanup(func() { corsAllowed = parseCORSOrigins(corsOrigins) })
+
+			req := httptest.NewRequest(tt.method, "/api/checkrnc/132138279", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+			cors(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantAllow == "" {
+				// ni métodos ni cabeceras para un origen sin permiso
+				for k := range rec.Header() {
+					if strings.HasPrefix(k, "Access-Control-") {
+						t.Errorf("unexpected %s header", k)
+					}
+				}
+			}
+			if tt.origins == "none" && rec.Header().Get("Vary") != "" {
+				t.Errorf("Vary = %q with CORS disabled", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
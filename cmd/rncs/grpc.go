@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yolfry/rncs/rnc"
+	"github.com/yolfry/rncs/rncpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+/* ---------- gRPC ---------- */
+
+// Límites de SearchByName y BatchCheck, los mismos que en /api/search y
+// /api/checkrnc/batch.
+const (
+	grpcSearchLimit    = 20
+	grpcMaxSearchLimit = 100
+	grpcMaxBatch       = 1000
+)
+
+// newGRPCServer arma el servidor de --grpc-port: el servicio de
+// proto/rncs.proto sobre los mismos datos que la API HTTP, con reflection
+// para grpcurl y el mismo TLS, API keys, logs y métricas.
+func newGRPCServer() *grpc.Server {
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(grpcObserve, grpcAuth)}
+	if cfg := tlsConfig(); cfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	}
+	gs := grpc.NewServer(opts...)
+	rncpb.RegisterRNCServiceServer(gs, rncService{})
+	reflection.Register(gs)
+	return gs
+}
+
+// grpcAddr es la dirección de --grpc-port, en la misma interfaz que la API
+// HTTP (addr).
+func grpcAddr(addr string) string {
+	host, _, _ := net.SplitHostPort(addr)
+	return net.JoinHostPort(host, strconv.Itoa(grpcPort))
+}
+
+// rncService implementa rncpb.RNCServiceServer.
+type rncService struct {
+	rncpb.UnimplementedRNCServiceServer
+}
+
+func (rncService) CheckRNC(ctx context.Context, req *rncpb.CheckRNCRequest) (*rncpb.Empresa, error) {
+	s, err := storeListo()
+	if err != nil {
+		return nil, grpcStoreErr(err)
+	}
+	emp, err := rnc.Find(s, req.GetRnc())
+	countLookup(rncpb.RNCService_CheckRNC_FullMethodName, err == nil)
+	switch {
+	case errors.Is(err, rnc.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil: // mal formado o dígito verificador inválido
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return empresaPB(emp), nil
+}
+
+func (rncService) SearchByName(ctx context.Context, req *rncpb.SearchByNameRequest) (*rncpb.SearchByNameResponse, error) {
+	q := strings.TrimSpace(req.GetQuery())
+	if q == "" {
+		return nil, status.Error(codes.InvalidArgument, "query not provided")
+	}
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = grpcSearchLimit
+	}
+	if limit < 1 || limit > grpcMaxSearchLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", grpcMaxSearchLimit)
+	}
+	if req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset must be a non-negative integer")
+	}
+	s, err := storeListo()
+	if err != nil {
+		return nil, grpcStoreErr(err)
+	}
+	idx, ok := s.(*rnc.Index)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "not available with --backend="+backend)
+	}
+	found, total := idx.Search(q, limit, int(req.GetOffset()))
+	countLookup(rncpb.RNCService_SearchByName_FullMethodName, total > 0)
+	resp := &rncpb.SearchByNameResponse{Total: int32(total), Results: make([]*rncpb.Empresa, len(found))}
+	for i, e := range found {
+		resp.Results[i] = empresaPB(e)
+	}
+	return resp, nil
+}
+
+func (rncService) BatchCheck(ctx context.Context, req *rncpb.BatchCheckRequest) (*rncpb.BatchCheckResponse, error) {
+	if len(req.GetRncs()) > grpcMaxBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "too many RNCs (max %d)", grpcMaxBatch)
+	}
+	s, err := storeListo()
+	if err != nil {
+		return nil, grpcStoreErr(err)
+	}
+	found, missing := rnc.FindMany(s, req.GetRncs())
+	resp := &rncpb.BatchCheckResponse{Results: make([]*rncpb.Empresa, len(found)), NotFound: missing}
+	for i, e := range found {
+		resp.Results[i] = empresaPB(e)
+	}
+	return resp, nil
+}
+
+// empresaPB convierte emp al mensaje de proto/rncs.proto.
+func empresaPB(emp rnc.Empresa) *rncpb.Empresa {
+	return &rncpb.Empresa{
+		Rnc:              emp.RNC,
+		SocialName:       emp.SocialName,
+		ComercialName:    emp.ComercialName,
+		Status:           emp.Status,
+		EconomicActivity: emp.EconomicActivity,
+		PaymentRegime:    emp.PaymentRegime,
+		Category:         emp.Category,
+		Extra:            emp.Extra,
+	}
+}
+
+// grpcStoreErr es rnc.WriteStoreError para gRPC: UNAVAILABLE durante la carga,
+// que los clientes pueden reintentar, e INTERNAL en otro caso.
+func grpcStoreErr(err error) error {
+	if errors.Is(err, rnc.ErrNotReady) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	slog.Error("Error loading index", "err", err)
+	return status.Error(codes.Internal, "Error loading index")
+}
+
+// grpcAuth exige con --api-keys-file una clave en los metadatos
+// x-api-key o authorization ("Bearer <clave>"), como requireAPIKey.
+func grpcAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	keys := apiKeys.Load()
+	if keys == nil {
+		return handler(ctx, req)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	var key string
+	if v := md.Get("x-api-key"); len(v) > 0 {
+		key = v[0]
+	} else if v := md.Get("authorization"); len(v) > 0 {
+		key, _ = strings.CutPrefix(v[0], "Bearer ")
+	}
+	id, ok := matchKey(*keys, key)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "Missing or invalid API key")
+	}
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.keyID = id
+	}
+	return handler(ctx, req)
+}
+
+// grpcObserve registra cada llamada como logRequests e instrument: un log
+// con método, código, duración e IP, y las métricas con el método completo
+// como endpoint y el código gRPC como status.
+func grpcObserve(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ri := &requestInfo{}
+	resp, err := handler(context.WithValue(ctx, requestInfoKey{}, ri), req)
+	code := status.Code(err)
+	elapsed := time.Since(start)
+	requestDuration.WithLabelValues(info.FullMethod).Observe(elapsed.Seconds())
+	requestsTotal.WithLabelValues(info.FullMethod, code.String()).Inc()
+
+	attrs := []any{"method", info.FullMethod, "code", code.String(), "duration", elapsed}
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			attrs = append(attrs, "ip", host)
+		}
+	}
+	if ri.keyID != "" {
+		attrs = append(attrs, "key", ri.keyID)
+	}
+	slog.Info("grpc request", attrs...)
+	return resp, err
+}
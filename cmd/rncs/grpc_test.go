@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/yolfry/rncs/rncpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient sirve newGRPCServer en memoria y devuelve un cliente.
+func newGRPCClient(t *testing.T) rncpb.RNCServiceClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	gs := newGRPCServer()
+	go gs.Serve(ln)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return rncpb.NewRNCServiceClient(conn)
+}
+
+func TestGRPC(t *testing.T) {
+	loadTestCSV(t, testRows)
+	c := newGRPCClient(t)
+	ctx := context.Background()
+
+	checks := []struct {
+		rnc  string
+		code codes.Code
+		name string
+	}{
+		{"1-32-13827-9", codes.OK, "FERRETERIA AMERICANA SRL"},
+		{"131000012", codes.NotFound, ""},
+		{"132138278", codes.InvalidArgument, ""}, // dígito verificador
+		{"13213827X", codes.InvalidArgument, ""},
+	}
+	for _, tt := range checks {
+		emp, err := c.CheckRNC(ctx, &rncpb.CheckRNCRequest{Rnc: tt.rnc})
+		if status.Code(err) != tt.code || emp.GetSocialName() != tt.name {
+			t.Errorf("CheckRNC(%q) = %q, %v; want %q, %v", tt.rnc, emp.GetSocialName(), err, tt.name, tt.code)
+		}
+	}
+
+	searches := []struct {
+		req   *rncpb.SearchByNameRequest
+		code  codes.Code
+		total int32
+	}{
+		{&rncpb.SearchByNameRequest{Query: "ferreteria"}, codes.OK, 1},
+		{&rncpb.SearchByNameRequest{Query: "  "}, codes.InvalidArgument, 0},
+		{&rncpb.SearchByNameRequest{Query: "sa", Limit: 101}, codes.InvalidArgument, 0},
+		{&rncpb.SearchByNameRequest{Query: "sa", Offset: -1}, codes.InvalidArgument, 0},
+	}
+	for _, tt := range searches {
+		res, err := c.SearchByName(ctx, tt.req)
+		if status.Code(err) != tt.code || res.GetTotal() != tt.total {
+			t.Errorf("SearchByName(%v) = total %d, %v; want %d, %v", tt.req, res.GetTotal(), err, tt.total, tt.code)
+		}
+	}
+
+	batch, err := c.BatchCheck(ctx, &rncpb.BatchCheckRequest{Rncs: []string{"132138279", "131000012", "101010632"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for _, e := range batch.GetResults() {
+		found = append(found, e.GetRnc())
+	}
+	if !slices.Equal(found, []string{"132138279", "101010632"}) || !slices.Equal(batch.GetNotFound(), []string{"131000012"}) {
+		t.Errorf("BatchCheck = %v, notFound %v", found, batch.GetNotFound())
+	}
+	if _, err := c.BatchCheck(ctx, &rncpb.BatchCheckRequest{Rncs: make([]string, grpcMaxBatch+1)}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("BatchCheck of %d = %v, want InvalidArgument", grpcMaxBatch+1, err)
+	}
+}
+
+// TestGRPCLoading comprueba que durante la carga inicial las consultas
+// responden UNAVAILABLE, que los clientes pueden reintentar.
+func TestGRPCLoading(t *testing.T) {
+	useTestCSV(t, testRows)
+	startupLoading.Store(true)
+	t.Cleanup(func() { startupLoading.Store(false) })
+	c := newGRPCClient(t)
+
+	if _, err := c.CheckRNC(context.Background(), &rncpb.CheckRNCRequest{Rnc: "132138279"}); status.Code(err) != codes.Unavailable {
+		t.Errorf("CheckRNC while loading = %v, want Unavailable", err)
+	}
+}
+
+// TestGRPCAuth comprueba que con --api-keys-file se exige la clave en los
+// metadatos, como en la API HTTP.
+func TestGRPCAuth(t *testing.T) {
+	loadTestCSV(t, testRows)
+	keys := []apiKey{{id: "partner", key: []byte("s3cret")}}
+	apiKeys.Store(&keys)
+	t.Cleanup(func() { apiKeys.Store(nil) })
+	c := newGRPCClient(t)
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		code codes.Code
+	}{
+		{"sin clave", nil, codes.Unauthenticated},
+		{"clave errónea", metadata.Pairs("x-api-key", "nope"), codes.Unauthenticated},
+		{"x-api-key", metadata.Pairs("x-api-key", "s3cret"), codes.OK},
+		{"Bearer", metadata.Pairs("authorization", "Bearer s3cret"), codes.OK},
+	}
+	for _, tt := range tests {
+		ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+		if _, err := c.CheckRNC(ctx, &rncpb.CheckRNCRequest{Rnc: "132138279"}); status.Code(err) != tt.code {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.code)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/* ---------- Compresión gzip ---------- */
+
+// gzipMinSize es el tamaño a partir del cual vale la pena comprimir.
+const gzipMinSize = 1024
+
+var gzipPool = sync.Pool{New: func() any {
+	gz, _ := gzip.NewWriterLevel(io.Discard, gzip.BestSpeed)
+	return gz
+}}
+
+// gzipResponses comprime las respuestas de más de gzipMinSize bytes cuando el
+// cliente acepta gzip. Va por fuera de logRequests, así el log registra el
+// tamaño sin comprimir. /metrics queda fuera: promhttp negocia su propia
+// codificación.
+func gzipResponses(next http.Handler) http.Handler {
+	if !gzipEnabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipWriter{ResponseWriter: w, status: http.StatusOK}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip interpreta Accept-Encoding; "gzip;q=0" cuenta como rechazo.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		v, err := strconv.ParseFloat(q, 64)
+		return err == nil && v > 0
+	}
+	return false
+}
+
+// gzipWriter retiene los primeros gzipMinSize bytes para decidir si comprime;
+// hasta entonces tampoco envía las cabeceras.
+type gzipWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	started bool
+	gz      *gzip.Writer // nil si la respuesta va sin comprimir
+}
+
+func (g *gzipWriter) WriteHeader(code int) {
+	if !g.started {
+		g.status = code
+	}
+}
+
+// Unwrap permite a http.ResponseController llegar al writer original.
+func (g *gzipWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if !g.started {
+		g.buf = append(g.buf, b...)
+		if len(g.buf) < gzipMinSize {
+			return len(b), nil
+		}
+		if err := g.start(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+// Flush envía lo acumulado; las respuestas que se van enviando por partes se
+// comprimen aunque aún no lleguen a gzipMinSize.
+func (g *gzipWriter) Flush() {
+	if !g.started {
+		g.start(true)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+// start envía las cabeceras y lo acumulado, comprimido si compress y la
+// respuesta lo admite.
+func (g *gzipWriter) start(compress bool) error {
+	g.started = true
+	h := g.Header()
+	if compress && h.Get("Content-Encoding") == "" && bodyAllowed(g.status) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.gz = gzipPool.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if g.gz != nil {
+		_, err := g.gz.Write(buf)
+		return err
+	}
+	_, err := g.ResponseWriter.Write(buf)
+	return err
+}
+
+// close termina la respuesta: lo que no llegó a gzipMinSize sale tal cual.
+func (g *gzipWriter) close() {
+	if !g.started {
+		g.start(false)
+	}
+	if g.gz != nil {
+		g.gz.Close()
+		gzipPool.Put(g.gz)
+		g.gz = nil
+	}
+}
+
+// bodyAllowed indica si status admite cuerpo (y por tanto compresión).
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
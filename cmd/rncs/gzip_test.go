@@ -0,0 +1,59 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzipResponses(t *testing.T) {
+	big := strings.Repeat("x", 2*gzipMinSize)
+	h := gzipResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Query().Get("body"))
+	}))
+
+	tests := []struct {
+		name, accept, body string
+		gzipped            bool
+	}{
+		{"grande", "gzip, deflate", big, true},
+		{"pequeña", "gzip", "hola", false},
+		{"sin Accept-Encoding", "", big, false},
+		{"gzip rechazado", "gzip;q=0, br", big, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/search?body="+tt.body, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Encoding", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if got := rec.Header().Get("Content-Encoding") == "gzip"; got != tt.gzipped {
+				t.Fatalf("gzipped = %v, want %v", got, tt.gzipped)
+			}
+			body := rec.Body.String()
+			if tt.gzipped {
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = string(b)
+			}
+			if body != tt.body {
+				t.Errorf("body has %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
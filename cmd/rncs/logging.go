@@ -0,0 +1,129 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+/* ---------- Logging ---------- */
+
+// setupLogger instala el logger por defecto según --log-format, --log-level
+// y --quiet. Con slog.SetDefault también el paquete rnc escribe por él.
+func setupLogger() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return fmt.Errorf("invalid --log-level %q", logLevel)
+	}
+	if quiet {
+		level = slog.LevelError // solo errores, p. ej. los de fatal
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch logFormat {
+	case "text":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid --log-format %q (use text or json)", logFormat)
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// fatal registra el error y termina el proceso.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// unloggedPaths no pasan por el logger de peticiones.
+var unloggedPaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// logRequests emite un registro por petición con método, ruta, estado,
+// duración, IP y tamaño de la respuesta. El cuerpo solo con --log-bodies.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unloggedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+		if logBodies {
+			rec.body = &strings.Builder{}
+		}
+		info := &requestInfo{}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", cmp.Or(rec.status, http.StatusOK), // sin escribir nada también es 200
+			"duration", time.Since(start),
+			"ip", clientIP(r),
+			"bytes", rec.size,
+		}
+		if info.keyID != "" {
+			attrs = append(attrs, "key", info.keyID)
+		}
+		if rec.body != nil {
+			attrs = append(attrs, "body", rec.body.String())
+		}
+		slog.Info("request", attrs...)
+	})
+}
+
+// requestInfo lleva datos que los handlers internos aportan al log de la
+// petición.
+type requestInfo struct {
+	keyID string
+}
+
+type requestInfoKey struct{}
+
+// setKeyID anota el id de la API key usada en r.
+func setKeyID(r *http.Request, id string) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.keyID = id
+	}
+}
+
+// responseRecorder para capturar estado, tamaño y (opcionalmente) la salida
+type responseRecorder struct {
+	http.ResponseWriter
+	status int // 200 si el handler escribe sin llamar a WriteHeader
+	size   int
+	body   *strings.Builder // nil si no se registran cuerpos
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap permite a http.ResponseController llegar al writer original.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK // net/http envía 200 implícito
+	}
+	if r.body != nil && r.body.Len() < logBodyLimit {
+		r.body.Write(b[:min(len(b), logBodyLimit-r.body.Len())])
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
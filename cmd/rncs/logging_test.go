@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// captureLogs desvía el logger por defecto a un buffer (en JSON, una línea
+// por registro) mientras dura el test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+// TestLogRequestsJSON comprueba los campos del registro de una petición con
+// --log-format=json.
+func TestLogRequestsJSON(t *testing.T) {
+	logs := captureLogs(t)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"This RNC does not exist"}`))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/checkrnc/131000012", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	logRequests(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	var rec struct {
+		Msg      string `json:"msg"`
+		Method   string `json:"method"`
+		Path     string `json:"path"`
+		Status   int    `json:"status"`
+		Duration *int64 `json:"duration"`
+		IP       string `json:"ip"`
+		Bytes    int    `json:"bytes"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &rec); err != nil {
+		t.Fatalf("log is not one JSON record: %v\n%s", err, logs)
+	}
+	if rec.Msg != "request" || rec.Method != "GET" || rec.Path != "/api/checkrnc/131000012" || rec.Status != 404 ||
+		rec.Duration == nil || rec.IP != "192.0.2.1" || rec.Bytes != 35 {
+		t.Errorf("log record = %s", logs)
+	}
+}
+
+// TestLogRequestsBodies comprueba que el cuerpo de la respuesta solo se
+// registra con --log-bodies; el estado y los bytes siempre.
+func TestLogRequestsBodies(t *testing.T) {
+	const body = `{"rnc":"132138279","socialName":"SECRETO SRL"}`
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	})
+	tests := []struct {
+		name     string
+		bodies   bool // --log-bodies
+		wantBody bool
+	}{
+		{"por defecto", false, false},
+		{"con --log-bodies", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevBodies, prevLimit := logBodies, logBodyLimit
+			logBodies, logBodyLimit = tt.bodies, 1024
+			t.Cleanup(func() { logBodies, logBodyLimit = prevBodies, prevLimit })
+			logs := captureLogs(t)
+
+			logRequests(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/checkrnc/132138279", nil))
+
+			out := logs.String()
+			if !strings.Contains(out, `"bytes":`+strconv.Itoa(len(body))) || !strings.Contains(out, `"status":200`) {
+				t.Errorf("log without status and byte count: %s", out)
+			}
+			if got := strings.Contains(out, "SECRETO SRL"); got != tt.wantBody {
+				t.Errorf("body in log = %v, want %v: %s", got, tt.wantBody, out)
+			}
+		})
+	}
+}
+
+// TestLogRequestsStatus comprueba el estado registrado cuando el handler no
+// llama a WriteHeader o lo llama después de escribir.
+func TestLogRequestsStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    int
+	}{
+		{"Write sin WriteHeader", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }, 200},
+		{"sin escribir nada", func(w http.ResponseWriter, r *http.Request) {}, 200},
+		{"WriteHeader", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) }, 404},
+		{"WriteHeader tras Write", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+			w.WriteHeader(http.StatusInternalServerError)
+		}, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			rec := httptest.NewRecorder()
+			logRequests(tt.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+			if !strings.Contains(logs.String(), `"status":`+strconv.Itoa(tt.want)+",") || rec.Code != tt.want {
+				t.Errorf("sent %d, logged %s; want %d", rec.Code, logs, tt.want)
+			}
+		})
+	}
+}
+
+// TestOneLogLinePerRequest pasa peticiones por la cadena completa del
+// servidor: cada una debe dejar exactamente un registro, también las que
+// terminan en error, y las sondas ninguno.
+func TestOneLogLinePerRequest(t *testing.T) {
+	useTestCSV(t, testRows)
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	h := newHTTPHandler()
+	tests := []struct {
+		method, target, body string
+		lines                int
+	}{
+		{http.MethodGet, "/api/checkrnc/132138279", "", 1},
+		{http.MethodGet, "/api/checkrnc/131000012", "", 1}, // 404
+		{http.MethodGet, "/api/checkrnc/12a", "", 1},       // 400
+		{http.MethodPost, "/api/checkrnc/batch", `{"rncs":["132138279","101010632"]}`, 1},
+		{http.MethodGet, "/api/search?q=ferreteria", "", 1},
+		{http.MethodGet, "/api/validate/132138279", "", 1},
+		{http.MethodGet, "/api/status", "", 1},
+		{http.MethodDelete, "/api/status", "", 1}, // 405
+		{http.MethodGet, "/no/existe", "", 1},
+		{http.MethodGet, "/healthz", "", 0},
+		{http.MethodGet, "/readyz", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			logs := captureLogs(t)
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			var lines []string
+			if logs.Len() > 0 {
+				lines = strings.Split(strings.TrimSpace(logs.String()), "\n")
+			}
+			if len(lines) != tt.lines {
+				t.Fatalf("%d log lines, want %d:\n%s", len(lines), tt.lines, logs)
+			}
+			if tt.lines == 1 && !strings.Contains(lines[0], `"msg":"request"`) {
+				t.Errorf("not a request log: %s", lines[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+/* ---------- Caché LRU ---------- */
+
+// lruCache es un caché LRU acotado a max entradas, con expiración opcional
+// (ttl <= 0: sin expiración). Es seguro para uso concurrente.
+type lruCache[K comparable, V any] struct {
+	mu    sync.Mutex
+	max   int
+	ttl   time.Duration
+	ll    *list.List // frente = usado más recientemente
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key     K
+	val     V
+	expires time.Time
+}
+
+func newLRUCache[K comparable, V any](max int, ttl time.Duration) *lruCache[K, V] {
+	return &lruCache[K, V]{max: max, ttl: ttl, ll: list.New(), items: make(map[K]*list.Element)}
+}
+
+// Get devuelve el valor de key si existe y no ha expirado.
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*lruEntry[K, V])
+	if c.ttl > 0 && time.Now().After(e.expires) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	return e.val, true
+}
+
+// GetOrAdd devuelve el valor de key o, si no existe o expiró, guarda y
+// devuelve el que crea newVal. Todo ocurre con c.mu tomado, así dos llamadas
+// concurrentes con la misma key reciben el mismo valor; newVal debe ser barata.
+func (c *lruCache[K, V]) GetOrAdd(key K, newVal func() V) V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry[K, V])
+		if c.ttl <= 0 || time.Now().Before(e.expires) {
+			c.ll.MoveToFront(el)
+			return e.val
+		}
+	}
+	v := newVal()
+	c.add(key, v)
+	return v
+}
+
+// Add guarda val bajo key y descarta la entrada menos usada si se supera max;
+// con max 0 no guarda nada.
+func (c *lruCache[K, V]) Add(key K, val V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(key, val)
+}
+
+// add es Add con c.mu ya tomado.
+func (c *lruCache[K, V]) add(key K, val V) {
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry[K, V])
+		e.val, e.expires = val, expires
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry[K, V]{key: key, val: val, expires: expires})
+	for c.ll.Len() > c.max && c.ll.Len() > 0 {
+		old := c.ll.Back()
+		c.ll.Remove(old)
+		delete(c.items, old.Value.(*lruEntry[K, V]).key)
+	}
+}
+
+// Len devuelve el número de entradas, incluidas las expiradas aún no purgadas.
+func (c *lruCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRUEviction(t *testing.T) {
+	c := newLRUCache[string, int](2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a") // "b" pasa a ser la menos usada
+	c.Add("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("b not evicted")
+	}
+	for k, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Get(k); !ok || v != want {
+			t.Errorf("Get(%q) = %d, %v; want %d", k, v, ok, want)
+		}
+	}
+
+	off := newLRUCache[string, int](0, 0)
+	off.Add("a", 1)
+	if off.Len() != 0 {
+		t.Errorf("size 0: Len() = %d, want 0", off.Len())
+	}
+}
+
+func TestLRUTTL(t *testing.T) {
+	c := newLRUCache[string, int](10, 20*time.Millisecond)
+	c.Add("a", 1)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a missing before the TTL")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Error("a served after the TTL")
+	}
+}
+
+// TestLRUGetOrAddConcurrent comprueba que muchas llamadas simultáneas con la
+// misma key crean el valor una sola vez y todas reciben ese mismo valor.
+func TestLRUGetOrAddConcurrent(t *testing.T) {
+	c := newLRUCache[string, *int](10, time.Minute)
+	var created atomic.Int32
+	newVal := func() *int {
+		created.Add(1)
+		time.Sleep(time.Millisecond) // abre la ventana entre buscar y guardar
+		return new(int)
+	}
+
+	const n = 64
+	got := make([]*int, n)
+	var start, wg sync.WaitGroup
+	start.Add(1)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start.Wait()
+			got[i] = c.GetOrAdd("1.2.3.4", newVal)
+		}()
+	}
+	start.Done()
+	wg.Wait()
+
+	if created.Load() != 1 {
+		t.Errorf("newVal called %d times, want 1", created.Load())
+	}
+	for i, v := range got {
+		if v != got[0] {
+			t.Fatalf("call %d got a different value", i)
+		}
+	}
+}
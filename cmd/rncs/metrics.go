@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+/* ---------- Métricas Prometheus ---------- */
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rncs_requests_total",
+		Help: "HTTP requests handled, by endpoint and status code.",
+	}, []string{"endpoint", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rncs_request_duration_seconds",
+		Help:    "HTTP request latency, by endpoint.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	lookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rncs_lookups_total",
+		Help: "Index lookups, by endpoint and result (hit or miss).",
+	}, []string{"endpoint", "result"})
+
+	cedulaCacheTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rncs_cedula_cache_total",
+		Help: "Lookups in the cédula response cache, by result (hit or miss).",
+	}, []string{"result"})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "rncs_index_entries",
+		Help: "Entries in the RNC index (in memory or on disk).",
+	}, func() float64 {
+		if idx := currentStore(); idx != nil {
+			return float64(idx.Len())
+		}
+		return 0
+	})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "rncs_index_age_seconds",
+		Help: "Seconds since the CSV was last loaded into the index.",
+	}, func() float64 {
+		if idx := currentStore(); idx != nil {
+			return time.Since(idx.LoadedAt()).Seconds()
+		}
+		return 0
+	})
+)
+
+// countCedulaCache registra si una cédula se respondió desde el caché.
+func countCedulaCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cedulaCacheTotal.WithLabelValues(result).Inc()
+}
+
+// countLookup registra si una búsqueda encontró resultados.
+func countLookup(endpoint string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	lookupsTotal.WithLabelValues(endpoint, result).Inc()
+}
+
+// instrument registra conteo y latencia de un handler. endpoint es el patrón
+// de la ruta (no la URL) para no disparar la cardinalidad de las etiquetas.
+func instrument(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		handler(sw, r)
+		requestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+		requestsTotal.WithLabelValues(endpoint, strconv.Itoa(sw.status)).Inc()
+	}
+}
+
+// statusWriter solo recuerda el código de estado enviado.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+/* ---------- Llamadas salientes ---------- */
+
+// outboundTransport es el transporte de la descarga de la DGII y de la API
+// de cédulas: usa el proxy de HTTP_PROXY, HTTPS_PROXY y NO_PROXY, agrega
+// --ca-cert a las raíces del sistema y, con --insecure-skip-verify, no
+// verifica los certificados.
+func outboundTransport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+	if caCert == "" && !insecureSkipVerify {
+		return t, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caCert != "" {
+		pem, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, fmt.Errorf("--ca-cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("--ca-cert %s: no PEM certificates found", caCert)
+		}
+		cfg.RootCAs = pool
+	}
+	t.TLSClientConfig = cfg
+	return t, nil
+}
+
+// logOutbound deja en el log el proxy que se usará para cada destino (sin
+// la contraseña) y la verificación TLS vigente. En el CLI solo con
+// --log-level=debug, para no ensuciar cada consulta.
+func logOutbound() {
+	ca := "system"
+	if caCert != "" {
+		ca = "system + " + caCert
+	}
+	level := slog.LevelDebug
+	if foreground {
+		level = slog.LevelInfo
+	}
+	slog.Log(context.Background(), level, "Outbound HTTP configuration",
+		"dgii_proxy", proxyFor(sourceURL), "cedula_proxy", proxyFor(fmt.Sprintf(cedulaAPI, "0")), "ca", ca)
+	if insecureSkipVerify {
+		slog.Warn("TLS certificate verification is DISABLED for outbound calls (--insecure-skip-verify); use only in lab environments")
+	}
+}
+
+// proxyFor devuelve el proxy que ProxyFromEnvironment elige para rawURL, o
+// "direct".
+func proxyFor(rawURL string) string {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "direct"
+	}
+	var p *url.URL
+	if p, err = http.ProxyFromEnvironment(req); err != nil || p == nil {
+		return "direct"
+	}
+	return p.Redacted()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestOutboundTransport comprueba contra un servidor HTTPS con una CA propia
+// que la conexión falla sin --ca-cert y funciona con ella o con
+// --insecure-skip-verify, y que un archivo sin certificados es un error.
+func TestOutboundTransport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { caCert, insecureSkipVerify = "", false })
+
+	tests := []struct {
+		name     string
+		ca       string
+		insecure bool
+		wantErr  bool // outboundTransport falla
+		wantOK   bool // la petición al servidor funciona
+	}{
+		{"raíces del sistema", "", false, false, false},
+		{"--ca-cert", ca, false, false, true},
+		{"--insecure-skip-verify", "", true, false, true},
+		{"--ca-cert sin certificados", empty, false, true, false},
+		{"--ca-cert inexistente", filepath.Join(dir, "missing.pem"), false, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caCert, insecureSkipVerify = tt.ca, tt.insecure
+			tr, err := outboundTransport()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("outboundTransport: err = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer tr.CloseIdleConnections()
+			resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err == nil) != tt.wantOK {
+				t.Errorf("GET: err = %v, want success %v", err, tt.wantOK)
+			}
+		})
+	}
+}
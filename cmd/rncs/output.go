@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yolfry/rncs/rnc"
+)
+
+/* ---------- Salida del modo CLI ---------- */
+
+// checkOutputFormat valida --output.
+func checkOutputFormat() error {
+	switch outputFormat {
+	case "json", "csv", "plain":
+		return nil
+	}
+	return fmt.Errorf("invalid --output %q (use json, csv or plain)", outputFormat)
+}
+
+// printEmpresa escribe emp en el formato de --output. csv y plain solo
+// incluyen rnc, socialName, comercialName y status, en ese orden.
+func printEmpresa(w io.Writer, emp rnc.Empresa) {
+	printRow(w, emp, []string{emp.RNC, emp.SocialName, emp.ComercialName, emp.Status})
+}
+
+// printError escribe msg en el formato de --output: {"error": msg} en json,
+// y una fila "error<sep>msg" en csv y plain.
+func printError(w io.Writer, msg string) {
+	printRow(w, rnc.ErrorResponse{Error: msg}, []string{"error", msg})
+}
+
+func printRow(w io.Writer, v any, fields []string) {
+	switch outputFormat {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(fields)
+		cw.Flush()
+	case "plain":
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+	default:
+		var j []byte
+		if replMode { // una respuesta por línea
+			j, _ = json.Marshal(v)
+		} else {
+			j, _ = json.MarshalIndent(v, "", "  ")
+		}
+		fmt.Fprintln(w, string(j))
+	}
+}
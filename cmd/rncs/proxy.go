@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+/* ---------- IP del cliente ---------- */
+
+// trustedProxies son las redes de --trusted-proxies.
+var trustedProxies []netip.Prefix
+
+// parseTrustedProxies lee una lista de CIDR (o IP sueltas) separadas por comas.
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !strings.Contains(f, "/") {
+			addr, err := netip.ParseAddr(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --trusted-proxies entry %q", f)
+			}
+			out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --trusted-proxies entry %q", f)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+func trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP devuelve la IP del cliente. X-Forwarded-For solo se usa cuando la
+// conexión viene de un proxy de confianza: se recorre de derecha a izquierda
+// y se toma el primer salto que no sea de confianza. Un valor mal formado
+// detiene el recorrido en el último salto válido.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !trusted(addr) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop
+		if !trusted(hop) {
+			break
+		}
+	}
+	return addr.Unmap().String()
+}
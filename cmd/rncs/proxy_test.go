@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	var err error
+	if trustedProxies, err = parseTrustedProxies("10.0.0.0/8, 192.168.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { trustedProxies = nil })
+
+	tests := []struct {
+		name, remote string
+		xff          []string
+		want         string
+	}{
+		{"sin proxy", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"XFF de un cliente no confiable", "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"proxy de confianza", "10.1.2.3:5000", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"cadena de proxies", "10.1.2.3:5000", []string{"198.51.100.9, 192.168.1.1, 10.9.9.9"}, "198.51.100.9"},
+		{"el cliente falsea el primer salto", "10.1.2.3:5000", []string{"1.1.1.1, 198.51.100.9"}, "198.51.100.9"},
+		{"varias cabeceras", "10.1.2.3:5000", []string{"1.1.1.1", "198.51.100.9"}, "198.51.100.9"},
+		{"salto mal formado", "10.1.2.3:5000", []string{"basura, 192.168.1.1"}, "192.168.1.1"},
+		{"IPv4 mapeada en IPv6", "[::ffff:10.1.2.3]:5000", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"proxy sin XFF", "10.1.2.3:5000", nil, "10.1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, s := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.0/8,foo"} {
+		if _, err := parseTrustedProxies(s); err == nil {
+			t.Errorf("parseTrustedProxies(%q) accepted", s)
+		}
+	}
+	if p, err := parseTrustedProxies(" 10.0.0.1/8 , ::1 "); err != nil || len(p) != 2 || p[0].String() != "10.0.0.0/8" {
+		t.Errorf("parseTrustedProxies = %v, %v; want [10.0.0.0/8 ::1/128]", p, err)
+	}
+}
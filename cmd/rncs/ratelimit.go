@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yolfry/rncs/rnc"
+	"golang.org/x/time/rate"
+)
+
+/* ---------- Límite de peticiones por IP ---------- */
+
+// maxRateClients acota cuántas IP se recuerdan; las menos recientes se
+// olvidan, así una avalancha de IP falsas no hace crecer la memoria.
+const maxRateClients = 10000
+
+// ipLimiters guarda un token bucket por IP; nil si --rate es 0.
+var ipLimiters *lruCache[string, *rate.Limiter]
+
+// rateLimit aplica --rate y --burst por IP de cliente y responde 429 con
+// Retry-After al agotarse el bucket. Las sondas y /metrics quedan fuera.
+func rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ipLimiters == nil || unloggedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		lim := ipLimiters.GetOrAdd(clientIP(r), func() *rate.Limiter {
+			return rate.NewLimiter(rate.Limit(rateLimitRPS), rateBurst)
+		})
+		if res := lim.Reserve(); res.Delay() > 0 {
+			wait := res.Delay()
+			res.Cancel()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			rnc.WriteError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setupRateLimit prepara los limitadores según --rate; los buckets inactivos
+// se olvidan al cabo de un tiempo.
+func setupRateLimit() {
+	if rateLimitRPS <= 0 {
+		return
+	}
+	// Un bucket lleno tarda burst/rate en recargarse; pasado eso equivale a uno nuevo
+	ttl := time.Duration(float64(rateBurst)/rateLimitRPS*float64(time.Second)) + time.Minute
+	ipLimiters = newLRUCache[string, *rate.Limiter](maxRateClients, ttl)
+}
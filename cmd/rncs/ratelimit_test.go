@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRateLimit comprueba que al agotar el bucket de una IP se responde 429
+// con Retry-After, sin afectar a otras IP ni a las sondas.
+func TestRateLimit(t *testing.T) {
+	origRPS, origBurst := rateLimitRPS, rateBurst
+	rateLimitRPS, rateBurst = 0.5, 2
+	setupRateLimit()
+	t.Cleanup(func() { rateLimitRPS, rateBurst, ipLimiters = origRPS, origBurst, nil })
+	h := rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":40000"
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	for i := range 2 {
+		if rec := do("/api/checkrnc/132138279", "10.0.0.1"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst = %d, want 200", i+1, rec.Code)
+		}
+	}
+	rec := do("/api/checkrnc/132138279", "10.0.0.1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the burst = %d, want 429", rec.Code)
+	}
+	if ra := rec.Header().Get("Retry-After"); ra != "2" {
+		t.Errorf("Retry-After = %q, want 2 (one token every 2s)", ra)
+	}
+	if rec := do("/api/checkrnc/132138279", "10.0.0.2"); rec.Code != http.StatusOK {
+		t.Errorf("another IP = %d, want 200", rec.Code)
+	}
+	if rec := do("/healthz", "10.0.0.1"); rec.Code != http.StatusOK {
+		t.Errorf("/healthz = %d, want 200 (not rate limited)", rec.Code)
+	}
+}
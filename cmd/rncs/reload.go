@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yolfry/rncs/rnc"
+)
+
+/* ---------- Recarga en segundo plano ---------- */
+
+// Estados de reloadJob.
+const (
+	reloadIdle        = "idle"
+	reloadDownloading = "downloading"
+	reloadBuilding    = "building"
+	reloadDone        = "done"
+	reloadFailed      = "failed"
+)
+
+// reloadJob describe la última recarga, tal como la reporta
+// GET /api/reload/status.
+type reloadJob struct {
+	ID         string     `json:"id,omitempty"`
+	State      string     `json:"state"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Entries    int        `json:"entries,omitempty"`
+	// entradas antes de la recarga y duración, para detectar caídas bruscas
+	PreviousEntries int   `json:"previousEntries,omitempty"`
+	DurationMs      int64 `json:"durationMs,omitempty"`
+	NotModified     bool  `json:"notModified,omitempty"`
+}
+
+func (j reloadJob) running() bool {
+	return j.State == reloadDownloading || j.State == reloadBuilding
+}
+
+var (
+	reloadMu   sync.Mutex
+	lastReload = reloadJob{State: reloadIdle}
+)
+
+// startReload registra una recarga nueva. Si ya hay una en curso devuelve
+// esa y false; si la anterior empezó hace menos de --reload-interval,
+// devuelve además cuánto falta para poder iniciar otra.
+func startReload() (job reloadJob, retryAfter time.Duration, ok bool) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if lastReload.running() {
+		return lastReload, 0, false
+	}
+	if lastReload.StartedAt != nil {
+		if wait := reloadInterval - time.Since(*lastReload.StartedAt); wait > 0 {
+			return lastReload, wait, false
+		}
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	now := time.Now()
+	lastReload = reloadJob{ID: hex.EncodeToString(id), State: reloadDownloading, StartedAt: &now}
+	return lastReload, 0, true
+}
+
+// reloadPhase actualiza el estado de la recarga en curso.
+func reloadPhase(state string) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if lastReload.running() {
+		lastReload.State = state
+	}
+}
+
+// runReload ejecuta la recarga id con reload, guarda su resultado y lo
+// devuelve.
+func runReload(id string, reload func() error) (reloadJob, error) {
+	prev := 0
+	if s := currentStore(); s != nil {
+		prev = s.Len()
+	}
+	start := time.Now()
+	err := reload()
+	if err != nil && !errors.Is(err, rnc.ErrNotModified) {
+		slog.Error("Reload failed, keeping current data", "job", id, "err", err)
+	}
+
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if lastReload.ID != id {
+		return reloadJob{}, err
+	}
+	now := time.Now()
+	lastReload.FinishedAt = &now
+	lastReload.PreviousEntries, lastReload.DurationMs = prev, now.Sub(start).Milliseconds()
+	switch {
+	case errors.Is(err, rnc.ErrNotModified):
+		lastReload.State, lastReload.NotModified = reloadDone, true
+	case err != nil:
+		lastReload.State, lastReload.Error = reloadFailed, err.Error()
+	default:
+		lastReload.State = reloadDone
+	}
+	if idx := currentStore(); idx != nil {
+		lastReload.Entries = idx.Len()
+	}
+	return lastReload, err
+}
+
+// reloadResult es la respuesta de una recarga terminada.
+type reloadResult struct {
+	Status          string `json:"status"`
+	Entries         int    `json:"entries"`
+	PreviousEntries int    `json:"previousEntries"`
+	DurationMs      int64  `json:"durationMs"`
+}
+
+func reloaded(job reloadJob) reloadResult {
+	return reloadResult{Status: "reloaded", Entries: job.Entries, PreviousEntries: job.PreviousEntries, DurationMs: job.DurationMs}
+}
+
+// reloadFailedStatus es el código de una recarga fallida: 500 si el CSV
+// nuevo no tenía entradas suficientes (los datos actuales se conservan) y
+// code en otro caso.
+func reloadFailedStatus(err error, code int) int {
+	if errors.Is(err, rnc.ErrTooFewEntries) {
+		return http.StatusInternalServerError
+	}
+	return code
+}
+
+// reloadConflict es la respuesta 409 cuando ya hay una recarga en curso.
+type reloadConflict struct {
+	Error string `json:"error"`
+	ID    string `json:"id"`
+}
+
+// beginReload registra una recarga nueva o, si no se puede iniciar, responde
+// 503 (carga inicial en curso), 429 (antes de --reload-interval) o 409 (ya
+// hay una en curso).
+func beginReload(w http.ResponseWriter) (reloadJob, bool) {
+	if startupLoading.Load() {
+		rnc.WriteStoreError(w, rnc.ErrNotReady)
+		return reloadJob{}, false
+	}
+	job, retryAfter, ok := startReload()
+	if !ok && retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Round(time.Second)/time.Second)))
+		rnc.WriteError(w, http.StatusTooManyRequests, "Reload rate limit exceeded, try again later")
+		return job, false
+	}
+	if !ok {
+		rnc.WriteJSON(w, http.StatusConflict, reloadConflict{Error: "reload already in progress", ID: job.ID})
+		return job, false
+	}
+	return job, true
+}
+
+// handleReload atiende POST /api/reload. Por defecto inicia la recarga en
+// segundo plano y responde 202 con el id del trabajo; con ?wait=true espera
+// a que termine, como antes. Con --reload-token o --api-key exige
+// "Authorization: Bearer <token>" con cualquiera de los dos.
+func handleReload(w http.ResponseWriter, r *http.Request) {
+	if !rnc.AllowMethods(w, r, http.MethodPost) {
+		return
+	}
+	if !writeAllowed(r, reloadToken) {
+		unauthorized(w)
+		return
+	}
+	q := r.URL.Query()
+	force := q.Get("force") == "1" || q.Get("force") == "true"
+	wait := q.Get("wait") == "1" || q.Get("wait") == "true"
+
+	job, ok := beginReload(w)
+	if !ok {
+		return
+	}
+
+	if !wait {
+		// El trabajo sobrevive a la petición, pero no a --reload-timeout
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), reloadTimeout)
+		go func() {
+			defer cancel()
+			runReload(job.ID, func() error { return actualizarCSV(ctx, force) })
+		}()
+		rnc.WriteJSON(w, http.StatusAccepted, job)
+		return
+	}
+
+	// La descarga tarda minutos: este handler tiene su propio plazo
+	extendWriteDeadline(w, reloadTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), reloadTimeout)
+	defer cancel()
+	job, err := runReload(job.ID, func() error { return actualizarCSV(ctx, force) })
+	if errors.Is(err, rnc.ErrNotModified) {
+		rnc.WriteJSON(w, http.StatusOK, map[string]string{"status": "not-modified"})
+		return
+	}
+	if err != nil {
+		rnc.WriteError(w, reloadFailedStatus(err, http.StatusBadGateway), "Error reloading CSV: "+err.Error())
+		return
+	}
+	rnc.WriteJSON(w, http.StatusOK, reloaded(job))
+}
+
+// handleReloadLocal atiende POST /api/reload/local: vuelve a leer el CSV de
+// disco (o a extraer --from-zip) sin tocar la red, para cuando el archivo se
+// actualiza por fuera. Espera a que termine y responde con las entradas
+// cargadas. Comparte autorización, límite y estado con /api/reload.
+func handleReloadLocal(w http.ResponseWriter, r *http.Request) {
+	if !rnc.AllowMethods(w, r, http.MethodPost) {
+		return
+	}
+	if !writeAllowed(r, reloadToken) {
+		unauthorized(w)
+		return
+	}
+	job, ok := beginReload(w)
+	if !ok {
+		return
+	}
+	reloadPhase(reloadBuilding)
+	extendWriteDeadline(w, reloadTimeout)
+	ctx, cancel := context.WithTimeout(r.Context(), reloadTimeout)
+	defer cancel()
+	job, err := runReload(job.ID, func() error {
+		if currentStore() == nil {
+			return ensureIndex(ctx) // la primera carga ya lee el archivo actual
+		}
+		return recargarLocal(ctx)
+	})
+	if err != nil {
+		rnc.WriteError(w, http.StatusInternalServerError, "Error reloading CSV: "+err.Error())
+		return
+	}
+	rnc.WriteJSON(w, http.StatusOK, reloaded(job))
+}
+
+// handleReloadStatus atiende GET /api/reload/status.
+func handleReloadStatus(w http.ResponseWriter, r *http.Request) {
+	if !rnc.AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	reloadMu.Lock()
+	job := lastReload
+	reloadMu.Unlock()
+	rnc.WriteJSON(w, http.StatusOK, job)
+}
+
+// validBearer compara en tiempo constante el token Bearer de r con token.
+func validBearer(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+// resetReloads olvida la última recarga para que el test no dependa de
+// --reload-interval ni de otros tests.
+func resetReloads(t *testing.T) {
+	t.Helper()
+	reloadMu.Lock()
+	lastReload = reloadJob{State: reloadIdle}
+	reloadMu.Unlock()
+	t.Cleanup(func() {
+		reloadMu.Lock()
+		lastReload = reloadJob{State: reloadIdle}
+		reloadMu.Unlock()
+	})
+}
+
+func TestReloadLocal(t *testing.T) {
+	path := useTestCSV(t, testRows)
+	resetReloads(t)
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rows := testRows + "131000012,ACME SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	if err := os.WriteFile(path, []byte(testHeader+rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, body := do(t, http.MethodPost, "/api/reload/local", "")
+	var res reloadResult
+	if err := json.Unmarshal([]byte(body), &res); err != nil || code != http.StatusOK ||
+		res.Status != "reloaded" || res.Entries != 3 || res.PreviousEntries != 2 {
+		t.Fatalf("POST /api/reload/local = %d %s, want 200 with 3 entries, 2 before", code, body)
+	}
+	if _, err := consultarRNC(context.Background(), "131000012"); err != nil {
+		t.Errorf("new row not served after the reload: %v", err)
+	}
+	// --reload-interval también vale para la recarga local
+	if code, _ := do(t, http.MethodPost, "/api/reload/local", ""); code != http.StatusTooManyRequests {
+		t.Errorf("second reload = %d, want 429", code)
+	}
+	if code, _ := get(t, "/api/reload/local"); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/reload/local = %d, want 405", code)
+	}
+}
+
+// TestReloadEmptyCSV comprueba que un CSV sin entradas nunca reemplaza los
+// datos, ni siquiera con --min-reload-entries 0.
+func TestReloadEmptyCSV(t *testing.T) {
+	path := useTestCSV(t, testRows)
+	resetReloads(t)
+	orig := minReloadEntries
+	minReloadEntries = 0
+	t.Cleanup(func() { minReloadEntries = orig })
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(testHeader), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, body := do(t, http.MethodPost, "/api/reload/local", ""); code != http.StatusInternalServerError || !strings.Contains(body, "too few entries") {
+		t.Errorf("reload of an empty CSV = %d %s, want 500", code, body)
+	}
+	if n := currentStore().Len(); n != 2 {
+		t.Errorf("%d entries after the rejected reload, want the previous 2", n)
+	}
+}
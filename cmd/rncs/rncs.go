@@ -0,0 +1,1492 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yolfry/rncs/rnc"
+	"google.golang.org/grpc"
+)
+
+/* ---------- Custom Help ---------- */
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `
+rncs  —  RNC lookup in DGII CSV
+
+USAGE (CLI mode):
+  %[1]s <RNC>
+  %[1]s --version
+  %[1]s --full 132138279         (every DGII column of the record)
+  %[1]s --status                 (entries, data version, CSV date and size)
+  %[1]s --validate new.csv       (parse a CSV without serving it: rows,
+                                  skipped short rows, duplicate RNCs; exit 2
+                                  if unreadable or empty)
+  %[1]s --preload                (build the index and its snapshot, then
+                                  exit, so later lookups start warm)
+  %[1]s --repl < rncs.txt        (load the index once, then read one RNC per
+                                  line from stdin and print one result per
+                                  line: compact JSON, or csv/plain rows)
+
+Example:
+  %[1]s 132138279
+  %[1]s --csv /data/rncs.csv 132138279
+  %[1]s --from-zip /data/RNC_CONTRIBUYENTES.zip 132138279
+  %[1]s --output plain 132138279   (tab-separated: rnc, socialName,
+                                    comercialName, status; also csv)
+
+USAGE (API mode):
+  sudo %[1]s --foreground [port]
+
+  If [port] is not specified, 9922 is used. --host 127.0.0.1 restricts the
+  server to one interface (all interfaces by default).
+  The server listens at once and obtains the CSV and builds the index in
+  the background: until then /healthz answers 200, /readyz 503, and data
+  endpoints 503 {"error":"index loading"} with Retry-After. --wait-ready
+  loads everything before listening instead.
+  Use --listen HOST:PORT (e.g. 127.0.0.1:9922 or [::1]:9922) to bind a
+  specific interface; it takes precedence over [port].
+  With --tls-cert and --tls-key the server speaks HTTPS on the same port
+  (9922 by default); without them it serves plain HTTP. The certificate is
+  re-read on SIGHUP (e.g. after a Let's Encrypt renewal) and
+  --tls-min-version sets the oldest accepted protocol (1.2 by default).
+  /api/checkrnc sends an ETag (and X-Data-Version) that changes with the
+  data and answers 304 to a matching If-None-Match; --cache-max-age sets
+  the Cache-Control max-age (0 by default).
+  --backend=bolt serves RNC lookups from an on-disk bbolt database (built
+  once from the CSV, see --bolt-path) instead of memory; name, status and
+  prefix searches and /api/export answer 501 in that mode.
+  --backend=sqlite does the same with a SQLite file (see --sqlite-path).
+  Outbound calls (DGII download and api.digital.gob.do) go through the
+  proxy in HTTP_PROXY/HTTPS_PROXY/NO_PROXY. --ca-cert adds a PEM CA (e.g.
+  a corporate TLS-inspecting proxy) to the system roots and
+  --insecure-skip-verify disables certificate checks (lab use only). The
+  effective proxy and CA are logged at startup.
+  --offline is for air-gapped hosts: no outbound call is ever made. The
+  CSV must already be on disk (as with --csv, nothing is downloaded at
+  startup or by /api/reload, which re-reads the local file; --force is
+  rejected) and GET /api/checkcedula/ answers 501 instead of asking
+  api.digital.gob.do (unlike --cedula-mode=local, which still answers
+  with the check digit).
+  The parsed index is saved as a snapshot next to the CSV (rncs.idx, see
+  --index-cache) and reused at startup while the CSV's SHA-256 matches;
+  --no-snapshot always parses the CSV.
+  --delimiter sets the CSV separator (",", ";" or "\t"...) and
+  --header-rows the header rows: auto (default, detects whether the first
+  row is a header), 0 (no header, positional columns) or N (skip N rows,
+  the last one names the columns). Both also apply to --validate.
+  --encoding auto (default) reads UTF-8 if the first 64KB mostly are and
+  Windows-1252 otherwise; utf8 or win1252 force the encoding. Bytes that
+  are not valid UTF-8 in a UTF-8 file are read as Windows-1252, so names
+  with Ñ or accents never reach a response garbled.
+  --fields name=column,... (0-based columns) indexes extra CSV columns and
+  returns them in an "extra" object of each record, e.g.
+  --fields fecha_inicio=8 adds "extra":{"fecha_inicio":"..."}.
+  When the CSV repeats an RNC the last row wins and the count is logged and
+  reported by /api/status; --strict refuses such a CSV instead (at startup
+  and on reload).
+  Responses over 1KB are gzipped for clients that accept it (--gzip=false
+  turns this off).
+  Timeouts default to 5s read, 5s write and 60s idle (--read-timeout,
+  --write-timeout, --idle-timeout); /api/reload gets 5m (--reload-timeout)
+  and /api/export 10m (--export-timeout).
+  Exposed endpoints: GET  /api/checkrnc/{RNC}    (?full=1 returns every DGII
+                                                column; needs --full-index;
+                                                ?suggest=1 adds similar
+                                                existing RNCs on a miss)
+                    POST /api/checkrnc/batch   {"rncs":["...", ...]} (max 1000)
+                    POST /api/verify           {"rnc":"...","name":"..."} or an
+                                               array (max 1000): registered name,
+                                               similarity score and match per
+                                               --verify-threshold (0.85)
+                    GET  /api/validate/{RNC|CEDULA} (check digit only)
+                    GET  /api/checkcedula/{CEDULA} ({"cedula","valid","source"},
+                                                source digital.gob.do, local or
+                                                degraded; ?raw=1 passes the
+                                                upstream response through)
+                    GET  /api/search?q=NAME&limit=20&offset=0
+                    GET  /api/searchname/{NAME}?limit=50&offset=0 (max 200)
+                    GET  /api/suggest/{PREFIX}?limit=10 (RNCs starting with PREFIX)
+                    GET  /api/suggest?q=FERRE&limit=10 (names starting with q,
+                                                shortest first; q needs 3+ chars)
+                    GET  /api/rncs?status=ACTIVO&limit=100&offset=0 (max 1000)
+                    GET  /api/statuses         (distinct statuses with counts)
+                    GET  /api/export?format=ndjson|csv&status=ACTIVO
+                                               (stream the whole dataset)
+                    POST /api/reload           (hot reload CSV in the background,
+                                                202 + job id; ?wait=true blocks;
+                                                ?force=1 skips the change check)
+                    POST /api/reload/local     (re-read the CSV on disk, no download;
+                                                returns entries, previousEntries
+                                                and durationMs; 500 and no swap
+                                                if the new CSV has no entries)
+                    GET  /api/reload/status    (state of the last reload)
+                    GET  /api/status           (version, data version, entries,
+                                                duplicate RNCs in the CSV, load
+                                                time, CSV date and size, uptime,
+                                                reload in progress)
+                    GET  /healthz              (liveness probe; 200 while loading)
+                    GET  /readyz               (readiness probe; 503 until loaded)
+                    GET  /metrics              (Prometheus metrics)
+  --grpc-port N also serves the gRPC service of proto/rncs.proto
+  (CheckRNC, SearchByName, BatchCheck) on port N of the same host, with
+  server reflection for grpcurl. It shares TLS, API keys, logs, metrics
+  and graceful shutdown with HTTP; without the flag only HTTP is served.
+  --rate N limits each client IP to N requests/s (burst --burst), answering
+  429 with Retry-After when exceeded.
+  With --api-keys-file every /api/* request needs a key in X-Api-Key or
+  "Authorization: Bearer <key>" (send SIGHUP to re-read the file).
+  Write endpoints (POST /api/reload) require "Authorization: Bearer <key>"
+  when --api-key (or RNCS_API_KEY) is set; GET endpoints and the read-only
+  POST /api/checkrnc/batch and /api/verify stay open. /api/reload also
+  accepts the --reload-token (or RNCS_RELOAD_TOKEN) and allows one reload per
+  --reload-interval (10m by default).
+
+Flags:
+`, os.Args[0])
+	flag.PrintDefaults()
+	fmt.Println()
+}
+
+/* ---------- Flags ---------- */
+
+var (
+	foreground         bool
+	waitReady          bool
+	showVersion        bool
+	showStatus         bool
+	preload            bool
+	replMode           bool
+	validatePath       string
+	fullIndex          bool
+	fullRecord         bool
+	strictCSV          bool
+	fieldsSpec         string
+	delimiterFlag      string
+	csvEncoding        string // --encoding como rnc.Options.Encoding ("" = auto)
+	headerRowsFlag     string
+	csvDelimiter       rune           // --delimiter ya interpretado
+	csvHeaderRows      int            // --header-rows como rnc.Options.HeaderRows
+	extraFields        map[string]int // --fields ya interpretado
+	csvPath            string
+	minReloadEntries   int
+	shutdownTimeout    time.Duration
+	metricsEnabled     bool
+	gzipEnabled        bool
+	cacheMaxAge        time.Duration
+	verifyThreshold    float64
+	logFormat          string
+	logLevel           string
+	logBodies          bool
+	logBodyLimit       int
+	listen             string
+	grpcPort           int
+	indexCache         string
+	noSnapshot         bool
+	backend            string
+	boltDB             string
+	sqliteDB           string
+	tlsCert            string
+	tlsKey             string
+	tlsMinVersion      string
+	forceDownload      bool
+	outputFormat       string
+	csvURLs            stringList
+	downloadAttempts   int
+	downloadTimeout    time.Duration
+	quiet              bool
+	fromZip            string
+	csvLocal           bool // --csv explícito: usar ese archivo, nunca descargar
+	offlineCedula      bool
+	offline            bool // sin llamadas salientes: ni descargas ni API de cédulas
+	caCert             string
+	insecureSkipVerify bool
+	cedulaMode         string
+	cedulaCacheSize    int
+	cedulaCacheTTL     time.Duration
+	cedulaTimeout      time.Duration
+	breakerFailures    int
+	breakerCooldown    time.Duration
+	readTimeout        time.Duration
+	writeTimeout       time.Duration
+	idleTimeout        time.Duration
+	reloadTimeout      time.Duration
+	exportTimeout      time.Duration
+	reloadToken        string
+	writeKey           string
+	reloadInterval     time.Duration
+	apiKeysFile        string
+	bindHost           string
+	sourceURL          string
+	rateLimitRPS       float64
+	rateBurst          int
+	trustedProxyList   string
+	expectedSHA256     string
+	corsOrigins        string
+	corsMaxAge         time.Duration
+)
+
+// stringList es un flag que puede repetirse; cada uso agrega un valor.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+const csvFileName = "rncs.csv"
+
+func init() {
+	flag.BoolVar(&foreground, "foreground", false, "Run in API (HTTP) mode")
+	flag.BoolVar(&waitReady, "wait-ready", false, "API mode: obtain the CSV and load the index before listening, instead of listening at once and answering 503 until the index is ready")
+	flag.BoolVar(&fullIndex, "full-index", false, "Keep every DGII column in memory so /api/checkrnc/{RNC}?full=1 can return the whole record")
+	flag.BoolVar(&strictCSV, "strict", false, "Refuse to load (or reload) a CSV with duplicate RNCs instead of keeping the last row of each")
+	flag.StringVar(&delimiterFlag, "delimiter", ",", `CSV field separator, a single character ("\t" or "tab" for tabs)`)
+	flag.StringVar(&csvEncoding, "encoding", "auto", "CSV encoding: auto (UTF-8 if the first 64KB mostly are, otherwise Windows-1252), utf8 or win1252; stray non-UTF-8 bytes in a UTF-8 file are read as Windows-1252")
+	flag.StringVar(&headerRowsFlag, "header-rows", "auto", "Header rows at the top of the CSV: auto (detect whether the first row is a header), 0 (no header, positional columns) or N (skip N rows, the last one names the columns)")
+	flag.StringVar(&fieldsSpec, "fields", "", "Extra CSV columns to index and return under \"extra\", as name=column pairs (0-based), e.g. fecha_inicio=8,actividad=3")
+	flag.BoolVar(&fullRecord, "full", false, "CLI: print every DGII column of the record (implies --full-index)")
+	flag.BoolVar(&showStatus, "status", false, "Print the status of the local CSV (entries, data version, file date and size) and exit")
+	flag.BoolVar(&preload, "preload", false, "CLI: build the index (and its snapshot) before anything else; without an RNC, only that, so later invocations load it warm")
+	flag.BoolVar(&replMode, "repl", false, "CLI: load the index once, then read one RNC per line from stdin and print one result per line")
+	flag.StringVar(&validatePath, "validate", "", "Parse the CSV at this path without starting the server, print rows, skipped and duplicate counts, and exit (non-zero if it cannot be used)")
+	flag.BoolVar(&showVersion, "version", false, "Print version, git commit and build date, then exit")
+	flag.StringVar(&csvPath, "csv", csvFileName, "Path to a local DGII CSV file (.csv, .csv.gz or .zip); when given it must exist and nothing is downloaded")
+	flag.StringVar(&fromZip, "from-zip", "", "Local DGII ZIP to extract the CSV from instead of downloading")
+	flag.BoolVar(&forceDownload, "force", false, "Re-download the CSV from DGII at startup even if it already exists")
+	flag.StringVar(&sourceURL, "source-url", "", "URL of the DGII ZIP (default $RNCS_SOURCE_URL, or "+rnc.DefaultURL+")")
+	flag.Var(&csvURLs, "csv-url", "Mirror URL of the DGII ZIP, tried in order if the official one fails (repeatable)")
+	flag.StringVar(&expectedSHA256, "expected-sha256", "", "Expected SHA-256 (hex) of the downloaded ZIP; a mismatching download is discarded")
+	flag.StringVar(&caCert, "ca-cert", "", "PEM file with extra CA certificates trusted for outbound calls (DGII download and cédula API), added to the system roots")
+	flag.BoolVar(&insecureSkipVerify, "insecure-skip-verify", false, "DANGEROUS: do not verify TLS certificates of outbound calls (lab environments only)")
+	flag.IntVar(&downloadAttempts, "download-attempts", 3, "Attempts per URL on network errors or HTTP 5xx, with exponential backoff")
+	flag.DurationVar(&downloadTimeout, "download-timeout", 60*time.Second, "Timeout for each download of the DGII ZIP")
+	flag.IntVar(&minReloadEntries, "min-reload-entries", 1, "Minimum entries a new CSV must have to replace the current one (at least 1)")
+	flag.DurationVar(&readTimeout, "read-timeout", 5*time.Second, "HTTP server read timeout")
+	flag.DurationVar(&writeTimeout, "write-timeout", 5*time.Second, "HTTP server write timeout (except /api/reload and /api/export, see --reload-timeout and --export-timeout)")
+	flag.DurationVar(&idleTimeout, "idle-timeout", 60*time.Second, "HTTP keep-alive idle timeout")
+	flag.DurationVar(&exportTimeout, "export-timeout", 10*time.Minute, "Write deadline for /api/export, which streams the whole dataset")
+	flag.DurationVar(&reloadTimeout, "reload-timeout", 5*time.Minute, "Deadline for a /api/reload request, including the DGII download")
+	flag.StringVar(&writeKey, "api-key", "", "Bearer key required by write (POST) endpoints such as /api/reload (default $RNCS_API_KEY; empty = no auth)")
+	flag.StringVar(&reloadToken, "reload-token", "", "Bearer token required by /api/reload (default $RNCS_RELOAD_TOKEN; empty = no auth)")
+	flag.DurationVar(&reloadInterval, "reload-interval", 10*time.Minute, "Minimum time between two reloads (429 with Retry-After otherwise)")
+	flag.StringVar(&apiKeysFile, "api-keys-file", "", "File with one API key per line (optionally id:key); when set, /api/* requires X-Api-Key or Authorization: Bearer. Re-read on SIGHUP")
+	flag.StringVar(&trustedProxyList, "trusted-proxies", "", "Comma-separated CIDRs of reverse proxies whose X-Forwarded-For is trusted for the client IP (default: none, use the connection address)")
+	flag.StringVar(&corsOrigins, "cors-origins", "*", `Comma-separated origins allowed by CORS, "*" for any, "none" to disable CORS`)
+	flag.DurationVar(&corsMaxAge, "cors-max-age", 10*time.Minute, "How long browsers may cache a CORS preflight (Access-Control-Max-Age)")
+	flag.Float64Var(&rateLimitRPS, "rate", 0, "Requests per second allowed per client IP (0 = no limit); /healthz, /readyz and /metrics are exempt")
+	flag.IntVar(&rateBurst, "burst", 20, "Requests a client IP may make in a burst above --rate")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 10*time.Second, "Time to wait for in-flight requests on SIGINT/SIGTERM")
+	flag.Float64Var(&verifyThreshold, "verify-threshold", 0.85, "Minimum name similarity (0-1] for /api/verify to report a match")
+	flag.DurationVar(&cacheMaxAge, "cache-max-age", 0, "max-age of Cache-Control on /api/checkrnc responses (0: clients revalidate with If-None-Match)")
+	flag.BoolVar(&gzipEnabled, "gzip", true, "Gzip responses over 1KB when the client accepts it (use --gzip=false to disable)")
+	flag.BoolVar(&metricsEnabled, "metrics", true, "Expose Prometheus metrics at /metrics (use --metrics=false to disable)")
+	flag.StringVar(&cedulaMode, "cedula-mode", cedulaAuto, "How /api/checkcedula/ answers: auto (api.digital.gob.do, falling back to the local check digit when it fails), local (check digit only) or remote (api.digital.gob.do only, no fallback)")
+	flag.BoolVar(&offlineCedula, "offline-cedula", false, "Same as --cedula-mode=local")
+	flag.BoolVar(&offline, "offline", false, "Air-gapped mode: never make outbound calls. The CSV must already be on disk (it is never downloaded, not even by /api/reload) and /api/checkcedula/ answers 501")
+	flag.DurationVar(&cedulaTimeout, "cedula-timeout", 4*time.Second, "Timeout for each call to api.digital.gob.do (504 when exceeded)")
+	flag.IntVar(&breakerFailures, "cedula-breaker-failures", 5, "Consecutive api.digital.gob.do failures that pause calls to it (0 disables the circuit breaker)")
+	flag.DurationVar(&breakerCooldown, "cedula-breaker-cooldown", 30*time.Second, "How long calls to api.digital.gob.do stay paused after --cedula-breaker-failures")
+	flag.IntVar(&cedulaCacheSize, "cedula-cache-size", 10000, "Max cédula responses kept in memory (0 disables the cache)")
+	flag.DurationVar(&cedulaCacheTTL, "cedula-cache-ttl", 24*time.Hour, "How long a cached cédula response is served without asking api.digital.gob.do")
+	flag.StringVar(&backend, "backend", backendMemory, "Where lookups read the data from: memory (every endpoint), bolt or sqlite (on-disk database, RNC lookups only, for low-memory hosts)")
+	flag.StringVar(&boltDB, "bolt-path", "", "On-disk database for --backend=bolt (default: CSV path with .db extension)")
+	flag.StringVar(&sqliteDB, "sqlite-path", "", "On-disk database for --backend=sqlite (default: CSV path with .sqlite extension)")
+	flag.StringVar(&indexCache, "index-cache", "", "Binary index snapshot file, used at startup when it matches the SHA-256 of the CSV (default: CSV path with .idx extension)")
+	flag.BoolVar(&noSnapshot, "no-snapshot", false, "Neither read nor write the binary index snapshot; always parse the CSV")
+	flag.StringVar(&tlsCert, "tls-cert", "", "TLS certificate file (PEM); enables HTTPS together with --tls-key")
+	flag.StringVar(&tlsKey, "tls-key", "", "TLS private key file (PEM)")
+	flag.StringVar(&tlsMinVersion, "tls-min-version", "1.2", "Minimum TLS version: 1.0, 1.1, 1.2 or 1.3")
+	flag.StringVar(&outputFormat, "output", "json", "CLI output format: json, csv or plain (tab-separated)")
+	flag.StringVar(&bindHost, "host", "", "API bind host or IP, e.g. 127.0.0.1 (default: all interfaces)")
+	flag.StringVar(&listen, "listen", "", "API bind address, e.g. 127.0.0.1:9922 or [::1]:9922 (overrides [port])")
+	flag.IntVar(&grpcPort, "grpc-port", 0, "API mode: also serve the gRPC service of proto/rncs.proto on this port, same host as HTTP (0: HTTP only)")
+	flag.StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+	flag.StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	flag.BoolVar(&quiet, "quiet", false, "Only log errors (overrides --log-level); useful in shell pipelines")
+	flag.BoolVar(&logBodies, "log-bodies", false, "Include response bodies in request logs (debug)")
+	flag.IntVar(&logBodyLimit, "log-body-limit", 1024, "Max bytes of each response body logged with --log-bodies")
+	flag.Usage = usage
+	flag.Parse()
+	if showVersion {
+		// antes de validar nada o tocar el CSV
+		fmt.Println(versionString())
+		os.Exit(0)
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "csv" {
+			csvLocal = true
+		}
+	})
+	if sourceURL == "" {
+		sourceURL = cmp.Or(os.Getenv("RNCS_SOURCE_URL"), rnc.DefaultURL)
+	}
+	for _, u := range append([]string{sourceURL}, csvURLs...) {
+		if err := checkURL(u); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if expectedSHA256 != "" {
+		if b, err := hex.DecodeString(expectedSHA256); err != nil || len(b) != sha256.Size {
+			fmt.Fprintf(os.Stderr, "Error: invalid --expected-sha256 %q (64 hex digits)\n", expectedSHA256)
+			os.Exit(1)
+		}
+	}
+	for name, d := range map[string]time.Duration{
+		"read-timeout": readTimeout, "write-timeout": writeTimeout, "idle-timeout": idleTimeout,
+		"reload-timeout": reloadTimeout, "export-timeout": exportTimeout,
+		"download-timeout": downloadTimeout, "cedula-timeout": cedulaTimeout,
+		"cedula-breaker-cooldown": breakerCooldown,
+	} {
+		if d <= 0 {
+			fmt.Fprintf(os.Stderr, "Error: --%s must be a positive duration, got %s\n", name, d)
+			os.Exit(1)
+		}
+	}
+	if offline && forceDownload {
+		fmt.Fprintln(os.Stderr, "Error: --force downloads the CSV and cannot be used with --offline")
+		os.Exit(1)
+	}
+	if offline {
+		csvLocal = true // usar el archivo local, nunca descargar
+	}
+	if offlineCedula {
+		cedulaMode = cedulaLocal
+	}
+	if cedulaMode != cedulaAuto && cedulaMode != cedulaLocal && cedulaMode != cedulaRemote {
+		fmt.Fprintf(os.Stderr, "Error: invalid --cedula-mode %q (use auto, local or remote)\n", cedulaMode)
+		os.Exit(1)
+	}
+	if backend != backendMemory && backend != backendBolt && backend != backendSQLite {
+		fmt.Fprintf(os.Stderr, "Error: invalid --backend %q (use memory, bolt or sqlite)\n", backend)
+		os.Exit(1)
+	}
+	var err error
+	if csvDelimiter, err = parseDelimiter(delimiterFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	switch csvEncoding {
+	case "auto":
+		csvEncoding = rnc.EncodingAuto
+	case rnc.EncodingUTF8, rnc.EncodingWin1252:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid --encoding %q (use auto, utf8 or win1252)\n", csvEncoding)
+		os.Exit(1)
+	}
+	if csvHeaderRows, err = parseHeaderRows(headerRowsFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if extraFields, err = parseFields(fieldsSpec); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if backend != backendMemory && (fullIndex || fullRecord || strictCSV || extraFields != nil) {
+		fmt.Fprintln(os.Stderr, "Error: --full-index, --full, --strict and --fields need --backend=memory")
+		os.Exit(1)
+	}
+	if replMode && (foreground || showStatus || fullRecord || flag.NArg() > 0) {
+		fmt.Fprintln(os.Stderr, "Error: --repl reads RNCs from stdin and cannot be used with --foreground, --status, --full or an RNC argument")
+		os.Exit(1)
+	}
+	if grpcPort < 0 || grpcPort > 65535 {
+		fmt.Fprintf(os.Stderr, "Error: invalid --grpc-port %d\n", grpcPort)
+		os.Exit(1)
+	}
+	if verifyThreshold <= 0 || verifyThreshold > 1 {
+		fmt.Fprintf(os.Stderr, "Error: --verify-threshold must be in (0, 1], got %g\n", verifyThreshold)
+		os.Exit(1)
+	}
+	if rateLimitRPS < 0 || (rateLimitRPS > 0 && rateBurst < 1) {
+		// un bucket de tamaño 0 rechazaría todas las peticiones
+		fmt.Fprintln(os.Stderr, "Error: --rate must be >= 0 and --burst >= 1 when --rate is set")
+		os.Exit(1)
+	}
+	if trustedProxies, err = parseTrustedProxies(trustedProxyList); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if reloadToken == "" {
+		// por variable de entorno para no exponerlo en la lista de procesos
+		reloadToken = os.Getenv("RNCS_RELOAD_TOKEN")
+	}
+	if writeKey == "" {
+		writeKey = os.Getenv("RNCS_API_KEY")
+	}
+
+	if err := setupLogger(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := checkOutputFormat(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cedulaCacheSize < 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid --cedula-cache-size %d (use 0 to disable the cache)\n", cedulaCacheSize)
+		os.Exit(1)
+	}
+
+	if !offline { // con --offline no existe ningún cliente saliente
+		transport, err := outboundTransport()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		logOutbound()
+		httpClient = &http.Client{Timeout: downloadTimeout, Transport: transport}
+		cedulaClient = &http.Client{Timeout: cedulaTimeout, Transport: transport}
+		cedulaBreaker = &breaker{threshold: breakerFailures, cooldown: breakerCooldown}
+		downloader = &rnc.Downloader{
+			URL:      sourceURL,
+			Client:   httpClient,
+			Mirrors:  csvURLs,
+			Attempts: downloadAttempts,
+			SHA256:   expectedSHA256,
+		}
+	}
+	corsAllowed = parseCORSOrigins(corsOrigins)
+	cedulaCache = newLRUCache[string, cedulaResult](cedulaCacheSize, cedulaCacheTTL)
+	setupRateLimit()
+}
+
+/* ---------- Índice en memoria ---------- */
+
+var (
+	indexPtr atomic.Pointer[rnc.Index] // índice publicado; nil hasta la primera carga
+	diskPtr  atomic.Pointer[diskStore] // en su lugar con --backend=bolt o sqlite
+	loadMu   sync.Mutex
+	idxLoad  *indexLoad // carga en curso, compartida por quienes la esperan
+	loadErr  error      // error de la última carga fallida, para storeListo
+
+	// startupLoading es true mientras el modo API obtiene el CSV y carga el
+	// índice en segundo plano (sin --wait-ready); ensureIndex responde
+	// rnc.ErrNotReady en lugar de esperar.
+	startupLoading atomic.Bool
+)
+
+// indexLoad es una construcción del índice en curso; err es válido tras done.
+type indexLoad struct {
+	done chan struct{}
+	err  error
+}
+
+// diskStore es un backend en disco: rnc.BoltStore o rnc.SQLiteStore.
+type diskStore interface {
+	rnc.Store
+	ReplaceContext(ctx context.Context, path string, minEntries int) error
+	Close() error
+}
+
+// currentIndex devuelve el índice publicado, o nil si aún no se ha cargado.
+func currentIndex() *rnc.Index {
+	return indexPtr.Load()
+}
+
+// currentStore devuelve los datos publicados, en memoria o en disco según
+// --backend, o nil si aún no se han cargado.
+func currentStore() rnc.Store {
+	if s := diskPtr.Load(); s != nil {
+		return *s
+	}
+	if idx := currentIndex(); idx != nil {
+		return idx
+	}
+	return nil
+}
+
+// ensureIndex carga el índice si aún no se ha publicado. Las llamadas
+// concurrentes esperan a una única carga; si esta falla, la próxima llamada
+// lo vuelve a intentar en lugar de recordar el error. Cancelar ctx solo deja
+// de esperar: la carga es compartida y sigue para los demás.
+func ensureIndex(ctx context.Context) error {
+	if currentStore() != nil {
+		return nil
+	}
+	if startupLoading.Load() {
+		return rnc.ErrNotReady
+	}
+	loadMu.Lock()
+	if currentStore() != nil {
+		loadMu.Unlock()
+		return nil
+	}
+	if l := idxLoad; l != nil {
+		loadMu.Unlock()
+		select {
+		case <-l.done:
+			return l.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	l := &indexLoad{done: make(chan struct{})}
+	idxLoad = l
+	loadMu.Unlock()
+
+	err := loadStore()
+	l.err = err
+	loadMu.Lock()
+	idxLoad, loadErr = nil, err
+	loadMu.Unlock()
+	close(l.done)
+	return err
+}
+
+// storeListo devuelve los datos publicados sin esperar a que se carguen: si
+// aún no los hay, lanza la carga en segundo plano (salvo que ya esté en
+// curso) y devuelve rnc.ErrNotReady, que la API responde con 503 y
+// Retry-After. Si la carga anterior falló devuelve ese error mientras lo
+// vuelve a intentar.
+func storeListo() (rnc.Store, error) {
+	if s := currentStore(); s != nil {
+		return s, nil
+	}
+	if startupLoading.Load() {
+		return nil, rnc.ErrNotReady
+	}
+	loadMu.Lock()
+	loading, err := idxLoad != nil, loadErr
+	loadMu.Unlock()
+	if !loading {
+		go func() {
+			if err := ensureIndex(context.Background()); err != nil {
+				slog.Error("Could not load index", "err", err)
+			}
+		}()
+	}
+	if err == nil {
+		err = rnc.ErrNotReady
+	}
+	return nil, err
+}
+
+// loadStore carga y publica los datos: el índice en memoria o, con
+// --backend=bolt o sqlite, la base en disco (que se reconstruye si el CSV
+// cambió).
+func loadStore() error {
+	var (
+		s   diskStore
+		err error
+	)
+	switch backend {
+	case backendBolt:
+		s, err = rnc.OpenBoltOptions(csvPath, boltPath(), indexOptions())
+	case backendSQLite:
+		s, err = rnc.OpenSQLiteOptions(csvPath, sqlitePath(), indexOptions())
+	}
+	if backend != backendMemory {
+		if err == nil {
+			diskPtr.Store(&s)
+		}
+		return err
+	}
+	idx, err := loadIndex()
+	if err == nil {
+		indexPtr.Store(idx)
+	}
+	return err
+}
+
+// loadIndex usa el caché binario si corresponde al CSV actual y, si no,
+// parsea el CSV y regenera el caché. Con --no-snapshot siempre parsea.
+func loadIndex() (*rnc.Index, error) {
+	if noSnapshot {
+		return rnc.NewIndexFromCSVOptions(csvPath, indexOptions())
+	}
+	cache := indexCachePath()
+	idx, err := rnc.LoadCacheOptions(csvPath, cache, indexOptions())
+	if err == nil {
+		slog.Info("Index loaded from cache", "path", cache, "entries", idx.Len())
+		return idx, nil
+	}
+	if errors.Is(err, rnc.ErrDuplicateRNC) { // --strict: el CSV dará lo mismo
+		return nil, err
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Ignoring index cache", "path", cache, "err", err)
+	}
+	idx, err = rnc.NewIndexFromCSVOptions(csvPath, indexOptions())
+	if err != nil {
+		return nil, err
+	}
+	saveIndexCache(idx)
+	return idx, nil
+}
+
+// indexOptions traduce las flags que afectan a la construcción del índice.
+func indexOptions() rnc.Options {
+	return rnc.Options{
+		Full: fullIndex || fullRecord, Strict: strictCSV, Fields: extraFields,
+		Delimiter: csvDelimiter, HeaderRows: csvHeaderRows, Encoding: csvEncoding,
+	}
+}
+
+// parseDelimiter lee --delimiter: un solo carácter, o "\t"/"tab" para el
+// tabulador. La coma se devuelve como 0, el valor por defecto de
+// rnc.Options.
+func parseDelimiter(s string) (rune, error) {
+	if s == `\t` || s == "tab" {
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid --delimiter %q (use a single character other than a quote or newline)", s)
+	}
+	if r == ',' {
+		return 0, nil
+	}
+	return r, nil
+}
+
+// parseHeaderRows lee --header-rows: auto, 0 (rnc.NoHeader) o N > 0.
+func parseHeaderRows(s string) (int, error) {
+	if s == "auto" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid --header-rows %q (use auto, 0 or a positive number)", s)
+	}
+	if n == 0 {
+		return rnc.NoHeader, nil
+	}
+	return n, nil
+}
+
+// parseFields lee --fields: pares nombre=columna separados por comas, con la
+// columna contada desde 0. Devuelve nil si s está vacío.
+func parseFields(s string) (map[string]int, error) {
+	var out map[string]int
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		name, col, ok := strings.Cut(f, "=")
+		name = strings.TrimSpace(name)
+		i, err := strconv.Atoi(strings.TrimSpace(col))
+		if !ok || name == "" || err != nil || i < 0 {
+			return nil, fmt.Errorf("invalid --fields entry %q (use name=column, column from 0)", f)
+		}
+		if _, dup := out[name]; dup {
+			return nil, fmt.Errorf("duplicate --fields name %q", name)
+		}
+		if out == nil {
+			out = make(map[string]int)
+		}
+		out[name] = i
+	}
+	return out, nil
+}
+
+func saveIndexCache(idx *rnc.Index) {
+	if noSnapshot {
+		return
+	}
+	if err := idx.SaveCache(indexCachePath()); err != nil {
+		slog.Warn("Could not write index cache", "err", err)
+	}
+}
+
+// Valores de --backend.
+const (
+	backendMemory = "memory"
+	backendBolt   = "bolt"
+	backendSQLite = "sqlite"
+)
+
+// boltPath devuelve --bolt-path o, por defecto, el CSV con extensión .db.
+func boltPath() string {
+	if boltDB != "" {
+		return boltDB
+	}
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + ".db"
+}
+
+// sqlitePath devuelve --sqlite-path o, por defecto, el CSV con extensión .sqlite.
+func sqlitePath() string {
+	if sqliteDB != "" {
+		return sqliteDB
+	}
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + ".sqlite"
+}
+
+// indexCachePath devuelve --index-cache o, por defecto, el CSV con extensión .idx.
+func indexCachePath() string {
+	if indexCache != "" {
+		return indexCache
+	}
+	return strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + ".idx"
+}
+
+/* ---------- Búsqueda ---------- */
+
+// Códigos de salida del modo CLI.
+const (
+	exitNotFound   = 1 // RNC inexistente o mal formado
+	exitIndexError = 2 // no se pudo cargar el índice (CSV ausente o corrupto)
+)
+
+// consultarRNC busca id en los datos publicados, cargándolos si hace falta.
+// ctx es el de la petición: si el cliente se va, deja de esperar la carga.
+func consultarRNC(ctx context.Context, id string) (rnc.Empresa, error) {
+	if err := ensureIndex(ctx); err != nil {
+		return rnc.Empresa{}, err
+	}
+	return rnc.Find(currentStore(), id)
+}
+
+// rncParecidos devuelve hasta rnc.MaxSimilar RNC existentes a un dígito (o una
+// transposición) de id, para sugerirlos cuando id no existe.
+func rncParecidos(id string) []string {
+	idx := currentIndex()
+	if idx == nil {
+		return nil
+	}
+	return idx.Similar(id, rnc.MaxSimilar)
+}
+
+// consultarRaw devuelve todas las columnas del registro de id; requiere
+// --full-index.
+func consultarRaw(ctx context.Context, id string) (map[string]string, error) {
+	if err := ensureIndex(ctx); err != nil {
+		return nil, err
+	}
+	return rnc.FindRaw(currentStore(), id)
+}
+
+/* ---------- main ---------- */
+
+func main() {
+	if len(os.Args) == 1 { // no arguments -> help
+		usage()
+		return
+	}
+
+	if foreground {
+		if err := checkTLSFiles(); err != nil {
+			fatal("Invalid TLS configuration", err)
+		}
+	}
+
+	if validatePath != "" {
+		// revisa otro archivo: nada que descargar ni cargar
+		runValidate(validatePath)
+		return
+	}
+
+	if foreground && !waitReady {
+		// escuchar ya: las sondas y los orquestadores ven el proceso vivo
+		// mientras se descarga el CSV y se construye el índice
+		startupLoading.Store(true)
+		go cargaInicial()
+		startHTTP()
+		return
+	}
+
+	// Ctrl+C debe poder interrumpir la descarga inicial y sus reintentos
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := ensureCSVExists(ctx, csvPath)
+	stop()
+	if err != nil {
+		fatal("Could not obtain the CSV file", err)
+	}
+
+	if foreground { // --wait-ready
+		if err := ensureIndex(context.Background()); err != nil {
+			fatal("Could not load CSV", err)
+		}
+		startHTTP()
+	} else if showStatus {
+		runStatus()
+	} else if replMode {
+		if code := runREPL(os.Stdin, os.Stdout); code != 0 {
+			os.Exit(code)
+		}
+	} else if preload {
+		runPreload()
+	} else {
+		runCLI()
+	}
+}
+
+// cargaInicial obtiene el CSV y publica los datos mientras el servidor ya
+// atiende. Si falla el proceso termina, como con --wait-ready: sin datos no
+// hay nada que servir y el orquestador lo reiniciará.
+func cargaInicial() {
+	slog.Info("Loading index in the background; lookups answer 503 until it is ready")
+	err := ensureCSVExists(context.Background(), csvPath)
+	if err != nil {
+		fatal("Could not obtain the CSV file", err)
+	}
+	// nadie más carga mientras startupLoading esté activo
+	if err := loadStore(); err != nil {
+		fatal("Could not load CSV", err)
+	}
+	startupLoading.Store(false)
+	slog.Info("Index ready", "entries", currentStore().Len())
+}
+
+/* ---------- CLI ---------- */
+
+func runCLI() {
+	args := flag.Args()
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Error: missing RNC")
+		usage()
+		os.Exit(1)
+	}
+	id := args[0]
+	if fullRecord {
+		runFullCLI(id)
+		return
+	}
+
+	out, err := consultarRNC(context.Background(), id)
+	if err != nil {
+		code := exitNotFound
+		msg := err.Error()
+		if !errors.Is(err, rnc.ErrNotFound) && !errors.Is(err, rnc.ErrInvalidRNC) && !errors.Is(err, rnc.ErrMalformedRNC) {
+			code = exitIndexError
+			msg = "Error loading index: " + err.Error()
+		}
+		printError(os.Stdout, msg)
+		if code == exitNotFound {
+			if similar := rncParecidos(id); len(similar) > 0 {
+				fmt.Fprintf(os.Stderr, "Did you mean: %s?\n", strings.Join(similar, ", "))
+			}
+		}
+		os.Exit(code)
+	}
+	printEmpresa(os.Stdout, out)
+}
+
+// runPreload atiende --preload: construye el índice (o lo carga del
+// snapshot, que queda al día) y, si se pasó un RNC, lo consulta como runCLI.
+func runPreload() {
+	if err := ensureIndex(context.Background()); err != nil {
+		printError(os.Stdout, "Error loading index: "+err.Error())
+		os.Exit(exitIndexError)
+	}
+	if flag.NArg() > 0 {
+		runCLI()
+		return
+	}
+	slog.Info("Index ready", "entries", currentStore().Len())
+}
+
+// runREPL atiende --repl: carga el índice una sola vez y responde cada
+// línea no vacía de in con una línea en out, en el mismo orden. Los RNC que
+// no existen dan {"error": ...} y no detienen la lectura. Devuelve el código
+// de salida.
+func runREPL(in io.Reader, out io.Writer) int {
+	if err := ensureIndex(context.Background()); err != nil {
+		printError(out, "Error loading index: "+err.Error())
+		return exitIndexError
+	}
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		id := strings.TrimSpace(sc.Text())
+		if id == "" {
+			continue
+		}
+		emp, err := consultarRNC(context.Background(), id)
+		if err != nil {
+			printError(out, err.Error())
+			continue
+		}
+		printEmpresa(out, emp)
+	}
+	if err := sc.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runFullCLI imprime todas las columnas del registro (--full). En csv y
+// plain sale una fila nombre<sep>valor por columna.
+func runFullCLI(id string) {
+	raw, err := consultarRaw(context.Background(), id)
+	if err != nil {
+		code := exitNotFound
+		msg := err.Error()
+		if !errors.Is(err, rnc.ErrNotFound) && !errors.Is(err, rnc.ErrInvalidRNC) && !errors.Is(err, rnc.ErrMalformedRNC) {
+			code = exitIndexError
+			msg = "Error loading index: " + err.Error()
+		}
+		printError(os.Stdout, msg)
+		os.Exit(code)
+	}
+	if outputFormat == "json" {
+		printRow(os.Stdout, raw, nil)
+		return
+	}
+	cols := currentIndex().Columns()
+	if cols == nil { // CSV sin cabecera: column_1, column_2...
+		cols = slices.Sorted(maps.Keys(raw))
+	}
+	for _, col := range cols {
+		printRow(os.Stdout, nil, []string{col, raw[col]})
+	}
+}
+
+// runStatus atiende --status: carga el índice del CSV local e imprime lo
+// mismo que /api/status. En csv y plain: version, dataVersion, entries,
+// csvFileModTime y csvFileSize.
+func runStatus() {
+	if err := ensureIndex(context.Background()); err != nil {
+		printError(os.Stdout, "Error loading index: "+err.Error())
+		os.Exit(exitIndexError)
+	}
+	st := estadoServicio()
+	var mod string
+	if st.CSVFileModTime != nil {
+		mod = st.CSVFileModTime.Format(time.RFC3339)
+	}
+	printRow(os.Stdout, st, []string{st.Version, st.DataVersion, strconv.Itoa(st.Entries), mod, strconv.FormatInt(st.CSVFileSize, 10)})
+}
+
+// validateResult es la salida de --validate en json.
+type validateResult struct {
+	Path  string `json:"path"`
+	Valid bool   `json:"valid"`
+	rnc.CSVReport
+	Error string `json:"error,omitempty"`
+}
+
+// runValidate atiende --validate: lee el CSV de path sin construir el índice
+// e imprime cuántas filas tiene, cuántas se descartarían y cuántas repiten
+// RNC. Sale con exitIndexError si no se puede leer o no tiene entradas. En
+// csv y plain: path, valid, rows, entries, skipped y duplicates.
+func runValidate(path string) {
+	rep, err := rnc.ValidateCSV(path, indexOptions())
+	res := validateResult{Path: path, CSVReport: rep}
+	switch {
+	case err != nil:
+		res.Error = err.Error()
+	case rep.Entries == 0:
+		res.Error = "CSV has no entries"
+	default:
+		res.Valid = true
+	}
+	printRow(os.Stdout, res, []string{path, strconv.FormatBool(res.Valid), strconv.Itoa(rep.Rows),
+		strconv.Itoa(rep.Entries), strconv.Itoa(rep.Skipped), strconv.Itoa(rep.Duplicates)})
+	if !res.Valid {
+		if outputFormat != "json" {
+			fmt.Fprintln(os.Stderr, "Error:", res.Error)
+		}
+		os.Exit(exitIndexError)
+	}
+}
+
+/* ---------- HTTP + CORS Middleware ---------- */
+
+func startHTTP() {
+	addr, err := listenAddr()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		usage()
+		os.Exit(1)
+	}
+
+	if apiKeysFile != "" {
+		if err := reloadAPIKeys(); err != nil {
+			fatal("Could not load API keys", err)
+		}
+	}
+
+	listenAndServe(addr, newHTTPHandler())
+}
+
+// newHTTPHandler arma las rutas de la API con sus middlewares.
+func newHTTPHandler() http.Handler {
+	// Las consultas salen de rnc.NewHandler; aquí solo se añade lo propio
+	// del servicio: sondas, estado del proceso, cédulas, recargas y métricas.
+	mux := http.NewServeMux()
+	mux.Handle("/", rnc.NewHandler(nil,
+		rnc.WithStoreFunc(func(context.Context) (rnc.Store, error) {
+			return storeListo()
+		}),
+		// /api/status y /api/reload tienen su versión del servicio más abajo
+		rnc.WithoutRoutes("/api/status", "/api/reload"),
+		rnc.WithRouteWrapper(func(pattern string, h http.Handler) http.Handler {
+			return instrument(pattern, h.ServeHTTP)
+		}),
+		rnc.WithLookupHook(countLookup),
+		rnc.WithVerifyThreshold(verifyThreshold),
+		rnc.WithCacheMaxAge(cacheMaxAge),
+		rnc.WithExportTimeout(exportTimeout),
+	))
+
+	// GET /healthz: el proceso está vivo (sin log para no saturarlo)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		st := healthStatus{Status: "ok", Loading: startupLoading.Load()}
+		if idx := currentStore(); idx != nil {
+			st.Entries = idx.Len()
+			loaded := idx.LoadedAt()
+			st.LoadedAt = &loaded
+		}
+		rnc.WriteJSON(w, http.StatusOK, st)
+	})
+
+	// GET /readyz: el índice está cargado. Las recargas publican el índice
+	// nuevo de forma atómica, así que durante una recarga sigue listo.
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if currentStore() == nil {
+			if startupLoading.Load() {
+				w.Header().Set("Retry-After", strconv.Itoa(int(rnc.NotReadyRetryAfter/time.Second)))
+			}
+			rnc.WriteError(w, http.StatusServiceUnavailable, "index not ready")
+			return
+		}
+		rnc.WriteJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	})
+
+	// GET /api/status: frescura de los datos cargados
+	mux.HandleFunc("/api/status", instrument("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		if !rnc.AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+			return
+		}
+		st := estadoServicio()
+		st.Uptime = time.Since(startedAt).Round(time.Second).String()
+		if cedulaBreaker != nil && cedulaMode != cedulaLocal {
+			cb := cedulaBreaker.state()
+			st.CedulaAPI = &cb
+		}
+		rnc.WriteJSON(w, http.StatusOK, st)
+	}))
+
+	// GET /api/checkcedula/{CEDULA}
+	mux.HandleFunc("/api/checkcedula/", instrument("/api/checkcedula/", func(w http.ResponseWriter, r *http.Request) {
+		if offline {
+			rnc.WriteError(w, http.StatusNotImplemented, "Cedula lookups are disabled in offline mode (--offline)")
+			return
+		}
+		checkCedula(w, r)
+	}))
+	mux.HandleFunc("/api/reload", instrument("/api/reload", handleReload))
+	mux.HandleFunc("/api/reload/status", instrument("/api/reload/status", handleReloadStatus))
+	mux.HandleFunc("/api/reload/local", instrument("/api/reload/local", handleReloadLocal))
+
+	// GET /metrics (fuera del contador de peticiones)
+	if metricsEnabled {
+		mux.Handle("/metrics", promhttp.Handler())
+	}
+
+	// Logging middleware (por fuera del límite y las API keys para registrar
+	// los 429 y 401)
+	loggedMux := logRequests(rateLimit(requireAPIKey(mux)))
+
+	return cors(gzipResponses(loggedMux))
+}
+
+// listenAndServe atiende handler en addr hasta recibir SIGINT o SIGTERM.
+func listenAndServe(addr string, handler http.Handler) {
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		TLSConfig:    tlsConfig(),
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+
+	slog.Info("HTTP server", "version", version, "commit", commit, "addr", addr, "tls", tlsCert != "", "cors", corsAllowed)
+	var gs *grpc.Server
+	if grpcPort != 0 {
+		gs = newGRPCServer()
+		slog.Info("gRPC server", "addr", grpcAddr(addr), "tls", tlsCert != "")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	watchSIGHUP(ctx)
+	if err := serve(ctx, srv, gs); err != nil {
+		fatal("HTTP server error", err)
+	}
+}
+
+// watchSIGHUP vuelve a leer la configuración recargable (API keys y
+// certificado TLS) cada vez que llega SIGHUP, hasta que ctx se cancela.
+func watchSIGHUP(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+			}
+			slog.Info("SIGHUP received, reloading configuration")
+			if apiKeysFile != "" {
+				if err := reloadAPIKeys(); err != nil {
+					slog.Error("Could not reload API keys, keeping the previous ones", "err", err)
+				}
+			}
+			if tlsCert != "" {
+				if err := reloadCertificate(); err != nil {
+					slog.Error("Could not reload TLS certificate, keeping the previous one", "err", err)
+				}
+			}
+		}
+	}()
+}
+
+// serve atiende srv y, con --grpc-port, gs hasta que ctx se cancela, y
+// entonces los cierra ordenadamente: las peticiones en curso tienen
+// --shutdown-timeout para terminar. Un error de cualquiera de los dos
+// detiene ambos.
+func serve(ctx context.Context, srv *http.Server, gs *grpc.Server) error {
+	errc := make(chan error, 2)
+	go func() {
+		if tlsCert != "" {
+			errc <- srv.ListenAndServeTLS("", "") // certificado en srv.TLSConfig
+		} else {
+			errc <- srv.ListenAndServe()
+		}
+	}()
+	if gs != nil {
+		ln, err := net.Listen("tcp", grpcAddr(srv.Addr))
+		if err != nil {
+			srv.Close()
+			return fmt.Errorf("gRPC: %w", err)
+		}
+		go func() {
+			if err := gs.Serve(ln); err != nil {
+				errc <- fmt.Errorf("gRPC: %w", err)
+			}
+		}()
+	}
+
+	var serveErr error
+	select {
+	case serveErr = <-errc:
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	stopped := make(chan struct{})
+	if gs == nil {
+		close(stopped)
+	} else {
+		go func() {
+			gs.GracefulStop()
+			close(stopped)
+		}()
+		go func() {
+			select {
+			case <-stopped:
+			case <-sctx.Done():
+				gs.Stop() // corta las llamadas que no terminaron a tiempo
+			}
+		}()
+	}
+	defer func() { <-stopped }()
+	if serveErr != nil {
+		srv.Close()
+		return serveErr
+	}
+	if err := srv.Shutdown(sctx); err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+	return nil
+}
+
+// extendWriteDeadline amplía el plazo de escritura de la respuesta más allá
+// de --write-timeout, para handlers que pueden tardar minutos.
+func extendWriteDeadline(w http.ResponseWriter, d time.Duration) {
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(d)); err != nil {
+		slog.Warn("Could not extend write deadline", "err", err)
+	}
+}
+
+type healthStatus struct {
+	Status   string     `json:"status"`
+	Loading  bool       `json:"loading,omitempty"` // carga inicial en curso
+	Entries  int        `json:"entries,omitempty"`
+	LoadedAt *time.Time `json:"loadedAt,omitempty"`
+}
+
+// serviceStatus es la respuesta de /api/status y de --status.
+type serviceStatus struct {
+	Version        string     `json:"version"`
+	DataVersion    string     `json:"dataVersion,omitempty"`
+	Entries        int        `json:"entries"`
+	Duplicates     int        `json:"duplicates"` // filas del CSV con un RNC repetido
+	LoadedAt       *time.Time `json:"loadedAt,omitempty"`
+	CSVFileModTime *time.Time `json:"csvFileModTime,omitempty"`
+	CSVFileSize    int64      `json:"csvFileSize"`
+	Uptime         string     `json:"uptime,omitempty"` // solo en modo API
+	Reloading      bool       `json:"reloading"`
+	Loading        bool       `json:"loading,omitempty"` // carga inicial en curso (modo API)
+
+	// circuit breaker de la API de cédulas; solo en modo API y sin
+	// --cedula-mode=local
+	CedulaAPI *breakerState `json:"cedulaApi,omitempty"`
+}
+
+// startedAt es el arranque del proceso, para el uptime de /api/status.
+var startedAt = time.Now()
+
+// estadoServicio reúne la versión, los datos del índice cargado (tamaño,
+// versión, cuándo se cargó y fecha y tamaño del CSV del que salió) y si hay
+// una recarga en curso. No toca el disco.
+func estadoServicio() serviceStatus {
+	st := serviceStatus{Version: version, Loading: startupLoading.Load()}
+	if idx := currentStore(); idx != nil {
+		st.Entries, st.Duplicates = idx.Len(), idx.Duplicates()
+		st.DataVersion = idx.DataVersion()
+		loaded := idx.LoadedAt()
+		st.LoadedAt = &loaded
+		if mod, size := idx.SourceInfo(); !mod.IsZero() {
+			st.CSVFileModTime, st.CSVFileSize = &mod, size
+		}
+	}
+	reloadMu.Lock()
+	st.Reloading = lastReload.running()
+	reloadMu.Unlock()
+	return st
+}
+
+// listenAddr resuelve la dirección de escucha. --listen tiene prioridad
+// sobre --host y el puerto posicional, que se mantiene por compatibilidad.
+func listenAddr() (string, error) {
+	const defaultPort = 9922
+
+	args := flag.Args()
+	if len(args) > 1 {
+		return "", errors.New("too many arguments in API mode")
+	}
+	if listen != "" {
+		host, port, err := net.SplitHostPort(listen)
+		if err != nil {
+			return "", fmt.Errorf("invalid --listen %q: %v", listen, err)
+		}
+		if !validPort(port) {
+			return "", fmt.Errorf("invalid port in --listen %q", listen)
+		}
+		if len(args) == 1 {
+			slog.Warn("--listen overrides the positional port", "listen", listen, "port", args[0])
+		}
+		if bindHost != "" {
+			slog.Warn("--listen overrides --host", "listen", listen, "host", bindHost)
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(bindHost, "["), "]")
+	if host != "" && !validHost(host) {
+		return "", fmt.Errorf("invalid --host %q", bindHost)
+	}
+
+	port := strconv.Itoa(defaultPort)
+	if len(args) == 1 {
+		if !validPort(args[0]) {
+			return "", fmt.Errorf("invalid port \"%s\"", args[0])
+		}
+		port = args[0]
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// validHost acepta una IP o un nombre de host según RFC 1123.
+func validHost(h string) bool {
+	if net.ParseIP(h) != nil {
+		return true
+	}
+	if len(h) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(h, "."), ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func validPort(s string) bool {
+	p, err := strconv.Atoi(s)
+	return err == nil && p > 0 && p <= 65535
+}
+
+/* ---------- CSV existence ---------- */
+
+var (
+	httpClient *http.Client    // cliente saliente compartido (DGII y cédulas)
+	downloader *rnc.Downloader // configurado en init según los flags
+	csvOnce    sync.Once
+	csvErr     error
+)
+
+func ensureCSVExists(ctx context.Context, path string) error {
+	csvOnce.Do(func() {
+		csvErr = descargarCSV(ctx, path)
+	})
+	return csvErr
+}
+
+func descargarCSV(ctx context.Context, path string) error {
+	if fromZip != "" {
+		return extraerZIP(fromZip, path)
+	}
+	if csvLocal {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%w (nothing is downloaded with --csv or --offline)", err)
+		}
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		if !forceDownload {
+			return nil // Already exists
+		}
+		slog.Info("Refreshing CSV from DGII (--force)...")
+	} else {
+		slog.Info("CSV file not found, downloading from DGII...")
+	}
+	v, err := downloader.DownloadIfChanged(ctx, path, rnc.Version{})
+	if err != nil {
+		return err
+	}
+	saveSourceVersion(v)
+	return nil
+}
+
+// checkURL exige una URL http(s) absoluta para las descargas.
+func checkURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid download URL %q (must be http or https)", s)
+	}
+	return nil
+}
+
+// extraerZIP extrae el CSV de --from-zip en path si path no existe o es más
+// antiguo que el ZIP (o con --force). Nunca usa la red.
+func extraerZIP(zipPath, path string) error {
+	zst, err := os.Stat(zipPath)
+	if err != nil {
+		return fmt.Errorf("--from-zip: %w", err)
+	}
+	if st, err := os.Stat(path); err == nil && !forceDownload && !st.ModTime().Before(zst.ModTime()) {
+		return nil // ya extraído
+	}
+	slog.Info("Extracting CSV from local ZIP", "zip", zipPath, "path", path)
+	return rnc.ExtractCSV(zipPath, path)
+}
+
+// sourceVersionPath guarda ETag/Last-Modified de la última descarga aceptada.
+func sourceVersionPath() string {
+	return csvPath + ".meta"
+}
+
+func saveSourceVersion(v rnc.Version) {
+	if err := v.Save(sourceVersionPath()); err != nil {
+		slog.Warn("Could not save download metadata", "err", err)
+	}
+}
+
+// actualizarCSV descarga el padrón a un archivo temporal y solo si se puede
+// indexar lo mueve sobre csvPath y publica el índice nuevo. Si algo falla,
+// el CSV en disco y el índice en memoria quedan como estaban. Devuelve
+// rnc.ErrNotModified si la DGII no tiene una versión nueva, salvo con force.
+// Con --from-zip o --csv vuelve a leer el archivo local sin usar la red.
+func actualizarCSV(ctx context.Context, force bool) error {
+	if err := ensureIndex(ctx); err != nil {
+		// Sin índice no hay datos que preservar: basta con obtener el CSV
+		slog.Warn("No index loaded, fetching CSV before reloading", "err", err)
+		if err := descargarCSV(ctx, csvPath); err != nil {
+			return err
+		}
+		return ensureIndex(ctx)
+	}
+	if fromZip != "" || csvLocal {
+		return recargarLocal(ctx)
+	}
+	var prev rnc.Version
+	if !force {
+		var err error
+		if prev, err = rnc.LoadVersion(sourceVersionPath()); err != nil {
+			slog.Warn("Ignoring download metadata", "err", err)
+		}
+	}
+	tmp := csvPath + ".new"
+	defer os.Remove(tmp)
+	v, err := downloader.DownloadIfChanged(ctx, tmp, prev)
+	if err != nil {
+		return err
+	}
+	reloadPhase(reloadBuilding)
+	if err := reemplazarDatos(ctx, tmp); err != nil {
+		return err
+	}
+	saveSourceVersion(v)
+	return nil
+}
+
+// recargarLocal publica de nuevo el contenido de --from-zip o del CSV en
+// disco, con las mismas garantías que una descarga.
+func recargarLocal(ctx context.Context) error {
+	src := csvPath
+	if fromZip != "" {
+		src = csvPath + ".new"
+		defer os.Remove(src)
+		if err := rnc.ExtractCSV(fromZip, src); err != nil {
+			return err
+		}
+	}
+	reloadPhase(reloadBuilding)
+	return reemplazarDatos(ctx, src)
+}
+
+// reemplazarDatos publica el CSV src en el backend activo (ver
+// rnc.Index.Replace) y, en memoria, regenera el caché binario. Si ctx se
+// cancela a mitad, los datos publicados no cambian.
+func reemplazarDatos(ctx context.Context, src string) error {
+	if s := diskPtr.Load(); s != nil {
+		return (*s).ReplaceContext(ctx, src, max(minReloadEntries, 1))
+	}
+	idx := currentIndex()
+	if err := idx.ReplaceContext(ctx, src, max(minReloadEntries, 1)); err != nil {
+		return err
+	}
+	saveIndexCache(idx)
+	return nil
+}
@@ -0,0 +1,999 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yolfry/rncs/rnc"
+)
+
+// Los flags se leen en init(); testing.Init registra antes los de go test
+// para que flag.Parse los acepte.
+var _ = func() bool { testing.Init(); return true }()
+
+// TestMain deja que los tests ejecuten el binario: con RNCS_RUN_MAIN=1 el
+// proceso corre main() en lugar de los tests (ver runMain).
+func TestMain(m *testing.M) {
+	if os.Getenv("RNCS_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain ejecuta main() con args en un proceso aparte, con stdin como
+// entrada, y devuelve su salida estándar y el código de salida.
+func runMain(t *testing.T, stdin string, args ...string) (stdout string, code int) {
+	t.Helper()
+	stdout, _, code = runMainStderr(t, stdin, args...)
+	return stdout, code
+}
+
+// runMainStderr es como runMain pero devuelve también la salida de error.
+func runMainStderr(t *testing.T, stdin string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "RNCS_RUN_MAIN=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return out.String(), errOut.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, errOut.String())
+	}
+	return out.String(), errOut.String(), 0
+}
+
+// testHeader es la cabecera del padrón de la DGII.
+const testHeader = "RNC,RAZÓN SOCIAL,NOMBRE COMERCIAL,ACTIVIDAD ECONÓMICA,FECHA DE INICIO,ESTADO,RÉGIMEN DE PAGO\n"
+
+// writeTestCSV escribe testHeader más rows en un CSV temporal y devuelve su ruta.
+func writeTestCSV(t *testing.T, rows string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rncs.csv")
+	if err := os.WriteFile(path, []byte(testHeader+rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// useTestCSV apunta --csv a un CSV temporal con rows y descarta el índice
+// cargado; al terminar el test restaura ambos.
+func useTestCSV(t *testing.T, rows string) string {
+	t.Helper()
+	path := writeTestCSV(t, rows)
+	if err := flag.Set("csv", path); err != nil {
+		t.Fatal(err)
+	}
+	resetIndex()
+	t.Cleanup(func() {
+		flag.Set("csv", csvFileName)
+		resetIndex()
+	})
+	return path
+}
+
+// loadTestCSV es useTestCSV con el índice ya cargado: las rutas de consulta
+// no esperan la carga y mientras tanto responden 503.
+func loadTestCSV(t *testing.T, rows string) string {
+	t.Helper()
+	path := useTestCSV(t, rows)
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func resetIndex() {
+	loadMu.Lock()
+	idxLoad, loadErr = nil, nil
+	loadMu.Unlock()
+	indexPtr.Store(nil)
+	if s := diskPtr.Swap(nil); s != nil {
+		(*s).Close()
+	}
+}
+
+// testRows son dos empresas válidas para los tests de la API.
+const testRows = "" +
+	"132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n" +
+	"101010632,CONSTRUCTORA DEL CARIBE SA,CARIBE,CONSTRUCCION,01/01/2000,SUSPENDIDO,NORMAL\n"
+
+func TestCSVFlag(t *testing.T) {
+	path := useTestCSV(t, testRows)
+	emp, err := consultarRNC(context.Background(), "132138279")
+	if err != nil {
+		t.Fatalf("consultarRNC: %v", err)
+	}
+	if emp.SocialName != "FERRETERIA AMERICANA SRL" {
+		t.Errorf("socialName = %q, want the row from %s", emp.SocialName, path)
+	}
+}
+
+// do hace una petición a la API completa y devuelve el código y el cuerpo
+// de la respuesta.
+func do(t *testing.T, method, path, body string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	newHTTPHandler().ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec.Code, rec.Body.String()
+}
+
+func get(t *testing.T, path string) (int, string) {
+	t.Helper()
+	return do(t, http.MethodGet, path, "")
+}
+
+func TestProbes(t *testing.T) {
+	useTestCSV(t, testRows)
+	if code, _ := get(t, "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz before the index loads = %d, want 200", code)
+	}
+	if code, _ := get(t, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz before the index loads = %d, want 503", code)
+	}
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := get(t, "/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz after loading = %d, want 200", code)
+	}
+	if code, body := get(t, "/healthz"); code != http.StatusOK || !strings.Contains(body, `"entries":2`) {
+		t.Errorf("/healthz after loading = %d %s, want 200 with 2 entries", code, body)
+	}
+}
+
+// TestEnsureIndexCanceled comprueba que quien espera una carga ajena deja de
+// esperar cuando su contexto se cancela, sin afectar a la carga.
+func TestEnsureIndexCanceled(t *testing.T) {
+	useTestCSV(t, testRows)
+	l := &indexLoad{done: make(chan struct{})}
+	idxLoad = l
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := ensureIndex(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ensureIndex with a canceled ctx = %v, want context.Canceled", err)
+	}
+
+	want := errors.New("load failed")
+	l.err = want
+	close(l.done)
+	if err := ensureIndex(context.Background()); err != want {
+		t.Errorf("waiter of the shared load = %v, want %v", err, want)
+	}
+}
+
+// TestStartupLoading comprueba el servidor mientras la carga inicial corre en
+// segundo plano: vivo pero no listo, consultas en 503 con Retry-After y el
+// dígito verificador disponible; luego cargaInicial publica el índice.
+func TestStartupLoading(t *testing.T) {
+	useTestCSV(t, testRows)
+	startupLoading.Store(true)
+	t.Cleanup(func() { startupLoading.Store(false) })
+
+	tests := []struct {
+		method, path string
+		want         int
+		retryAfter   bool
+	}{
+		{http.MethodGet, "/healthz", http.StatusOK, false},
+		{http.MethodGet, "/readyz", http.StatusServiceUnavailable, true},
+		{http.MethodGet, "/api/checkrnc/132138279", http.StatusServiceUnavailable, true},
+		{http.MethodGet, "/api/search?q=ferreteria", http.StatusServiceUnavailable, true},
+		{http.MethodPost, "/api/reload", http.StatusServiceUnavailable, true},
+		{http.MethodGet, "/api/validate/132138279", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		newHTTPHandler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d %s, want %d", tt.method, tt.path, rec.Code, rec.Body, tt.want)
+		}
+		if got := rec.Header().Get("Retry-After") != ""; got != tt.retryAfter {
+			t.Errorf("%s %s: Retry-After = %q, want it set: %v", tt.method, tt.path, rec.Header().Get("Retry-After"), tt.retryAfter)
+		}
+	}
+	if _, body := get(t, "/healthz"); !strings.Contains(body, `"loading":true`) {
+		t.Errorf("/healthz while loading = %s, want loading:true", body)
+	}
+	if _, body := get(t, "/api/checkrnc/132138279"); !strings.Contains(body, "index loading") {
+		t.Errorf("lookup while loading = %s, want index loading", body)
+	}
+
+	cargaInicial()
+	if startupLoading.Load() {
+		t.Error("startupLoading still set after cargaInicial")
+	}
+	if code, body := get(t, "/api/checkrnc/132138279"); code != http.StatusOK {
+		t.Errorf("lookup after the initial load = %d %s, want 200", code, body)
+	}
+}
+
+// TestLazyLoad comprueba que una consulta sin datos cargados no espera la
+// carga: la lanza, responde 503 con Retry-After y las siguientes responden
+// 200 cuando termina. Una carga fallida se responde con 500 mientras se
+// reintenta.
+func TestLazyLoad(t *testing.T) {
+	const target = "/api/checkrnc/132138279"
+	h := newHTTPHandler()
+	poll := func() int {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+			if rec.Code != http.StatusServiceUnavailable || time.Now().After(deadline) {
+				return rec.Code
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	path := useTestCSV(t, testRows)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "5" {
+		t.Fatalf("first request = %d, Retry-After %q; want 503, 5", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if code := poll(); code != http.StatusOK {
+		t.Fatalf("after the load = %d, want 200", code)
+	}
+
+	resetIndex()
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if code := poll(); code != http.StatusInternalServerError {
+		t.Errorf("failed load = %d, want 500", code)
+	}
+	if err := os.WriteFile(path, []byte(testHeader+testRows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for code := poll(); code != http.StatusOK; code = poll() {
+		if time.Now().After(deadline) {
+			t.Fatalf("after the CSV came back = %d, want 200", code)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestDiskBackends comprueba que con --backend=bolt o sqlite las consultas
+// por RNC salen de la base en disco y los endpoints que recorren el padrón
+// responden 501.
+func TestDiskBackends(t *testing.T) {
+	for _, tt := range []struct{ backend, ext string }{{backendBolt, ".db"}, {backendSQLite, ".sqlite"}} {
+		t.Run(tt.backend, func(t *testing.T) {
+			path := useTestCSV(t, testRows)
+			backend = tt.backend
+			t.Cleanup(func() { backend = backendMemory })
+			if err := ensureIndex(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if code, body := get(t, "/api/checkrnc/1-32-13827-9"); code != http.StatusOK || !strings.Contains(body, "FERRETERIA AMERICANA SRL") {
+				t.Errorf("checkrnc = %d %s", code, body)
+			}
+			if currentIndex() != nil {
+				t.Errorf("--backend=%s loaded the index in memory", tt.backend)
+			}
+			if _, err := os.Stat(strings.TrimSuffix(path, ".csv") + tt.ext); err != nil {
+				t.Errorf("database not next to the CSV: %v", err)
+			}
+			code, body := do(t, http.MethodPost, "/api/checkrnc/batch", `{"rncs":["132138279","131000012"]}`)
+			if code != http.StatusOK || !strings.Contains(body, `"notFound":["131000012"]`) {
+				t.Errorf("batch = %d %s", code, body)
+			}
+			for _, p := range []string{"/api/search?q=ferreteria", "/api/searchname/ferreteria", "/api/rncs?status=ACTIVO", "/api/export"} {
+				if code, _ := get(t, p); code != http.StatusNotImplemented {
+					t.Errorf("GET %s = %d, want 501", p, code)
+				}
+			}
+		})
+	}
+}
+
+// TestStrict comprueba que /api/status informa los RNC repetidos y que con
+// --strict un CSV con repetidos no se carga.
+func TestStrict(t *testing.T) {
+	dup := testRows + "1-32-13827-9,FERRETERIA AMERICANA SA,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	path := useTestCSV(t, dup)
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if code, body := get(t, "/api/status"); code != http.StatusOK || !strings.Contains(body, `"duplicates":1`) {
+		t.Errorf("status = %d %s, want 1 duplicate", code, body)
+	}
+	out, code := runMain(t, "", "-csv", path, "-strict", "132138279")
+	if code != exitIndexError || !strings.Contains(out, "duplicate RNC") {
+		t.Errorf("--strict: exit %d, output %q; want exit %d", code, out, exitIndexError)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"fecha_inicio=4", map[string]int{"fecha_inicio": 4}, false},
+		{" fecha_inicio = 4 , actividad=3,", map[string]int{"fecha_inicio": 4, "actividad": 3}, false},
+		{"fecha_inicio", nil, true},
+		{"=4", nil, true},
+		{"fecha_inicio=-1", nil, true},
+		{"fecha_inicio=x", nil, true},
+		{"a=1,a=2", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseFields(tt.in)
+		if (err != nil) != tt.wantErr || !maps.Equal(got, tt.want) {
+			t.Errorf("parseFields(%q) = %v, %v; want %v (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    rune
+		wantErr bool
+	}{
+		{",", 0, false},
+		{";", ';', false},
+		{"|", '|', false},
+		{`\t`, '\t', false},
+		{"tab", '\t', false},
+		{"\t", '\t', false},
+		{"", 0, true},
+		{";;", 0, true},
+		{`"`, 0, true},
+		{"\n", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDelimiter(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseDelimiter(%q) = %q, %v; want %q (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseHeaderRows(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"auto", 0, false},
+		{"0", rnc.NoHeader, false},
+		{"1", 1, false},
+		{"3", 3, false},
+		{"-1", 0, true},
+		{"uno", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseHeaderRows(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseHeaderRows(%q) = %d, %v; want %d (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestEncodingFlag(t *testing.T) {
+	path := writeTestCSV(t, "132138279,COMPA\xd1IA DOMINICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	out, code := runMain(t, "", "-csv", path, "-encoding", "win1252", "-output", "csv", "132138279")
+	if code != 0 || !strings.Contains(out, "COMPAÑIA DOMINICANA SRL") {
+		t.Errorf("--encoding win1252: exit %d, output %q", code, out)
+	}
+	if _, stderr, code := runMainStderr(t, "", "-encoding", "latin1"); code != 1 || !strings.Contains(stderr, "--encoding") {
+		t.Errorf("--encoding latin1: exit %d, stderr %q; want exit 1", code, stderr)
+	}
+}
+
+// TestMixedEncoding comprueba que una fila en Windows-1252 dentro de un CSV
+// UTF-8 sale como UTF-8 válido en JSON y en texto plano.
+func TestMixedEncoding(t *testing.T) {
+	path := writeTestCSV(t, "101010632,FERRETERÍA PEÑA SA,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"132138279,CA\xd1A \xc9XITO SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	for _, format := range []string{"json", "plain"} {
+		out, code := runMain(t, "", "-csv", path, "-output", format, "132138279")
+		if code != 0 || !utf8.ValidString(out) || !strings.Contains(out, "CAÑA ÉXITO SRL") {
+			t.Errorf("-output %s: exit %d, output %q", format, code, out)
+		}
+	}
+}
+
+func TestFieldsFlag(t *testing.T) {
+	path := writeTestCSV(t, testRows)
+	out, code := runMain(t, "", "-csv", path, "-fields", "fecha_inicio=4", "-output", "json", "132138279")
+	if code != 0 || !strings.Contains(out, `"fecha_inicio": "01/01/2000"`) {
+		t.Errorf("--fields: exit %d, output %s", code, out)
+	}
+	if _, stderr, code := runMainStderr(t, "", "-fields", "fecha_inicio=4", "-backend", "bolt"); code != 1 || !strings.Contains(stderr, "--backend=memory") {
+		t.Errorf("--fields with bolt: exit %d, stderr %q; want exit 1", code, stderr)
+	}
+}
+
+// TestNoSnapshot comprueba que el índice se guarda como snapshot junto al
+// CSV y que con --no-snapshot no se escribe.
+func TestNoSnapshot(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		useTestCSV(t, testRows)
+		noSnapshot = disabled
+		t.Cleanup(func() { noSnapshot = false })
+		if err := ensureIndex(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		_, err := os.Stat(indexCachePath())
+		if written := err == nil; written == disabled {
+			t.Errorf("--no-snapshot=%v: snapshot written = %v", disabled, written)
+		}
+	}
+}
+
+// TestOffline comprueba que con --offline /api/checkcedula/ responde 501 sin
+// cliente saliente, que /api/status no depende del circuit breaker y que
+// --force se rechaza.
+func TestOffline(t *testing.T) {
+	loadTestCSV(t, testRows)
+	prevBreaker := cedulaBreaker
+	offline, cedulaBreaker = true, nil
+	t.Cleanup(func() { offline, cedulaBreaker = false, prevBreaker })
+
+	if code, body := get(t, "/api/checkcedula/00113918205"); code != http.StatusNotImplemented || !strings.Contains(body, "offline") {
+		t.Errorf("checkcedula = %d %s, want 501", code, body)
+	}
+	if code, body := get(t, "/api/status"); code != http.StatusOK || strings.Contains(body, "cedulaApi") {
+		t.Errorf("status = %d %s, want 200 without the breaker state", code, body)
+	}
+	if code, _ := get(t, "/api/checkrnc/132138279"); code != http.StatusOK {
+		t.Errorf("checkrnc = %d, want 200", code)
+	}
+	if _, stderr, code := runMainStderr(t, "", "-offline", "-force"); code != 1 || !strings.Contains(stderr, "--offline") {
+		t.Errorf("--offline --force: exit %d, stderr %q; want exit 1", code, stderr)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	path := useTestCSV(t, testRows)
+	var st serviceStatus
+	_, body := get(t, "/api/status")
+	if err := json.Unmarshal([]byte(body), &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Entries != 0 || st.LoadedAt != nil || st.CSVFileModTime != nil {
+		t.Errorf("before loading: %s, want no entries, loadedAt nor CSV data", body)
+	}
+
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, body = get(t, "/api/status")
+	if err := json.Unmarshal([]byte(body), &st); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Entries != 2 || st.LoadedAt == nil || st.DataVersion == "" || st.Uptime == "" || st.Reloading ||
+		st.CSVFileSize != fi.Size() || st.CSVFileModTime == nil || !st.CSVFileModTime.Equal(fi.ModTime()) {
+		t.Errorf("after loading: %s, want 2 entries, data version, uptime and the CSV's date and size", body)
+	}
+}
+
+func TestStatusFlag(t *testing.T) {
+	path := writeTestCSV(t, testRows)
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, code := runMain(t, "", "-csv", path, "-status", "-output", "csv")
+	f := strings.Split(strings.TrimSpace(out), ",")
+	if code != 0 || len(f) != 5 || f[0] != "dev" || f[1] == "" || f[2] != "2" ||
+		f[3] != fi.ModTime().Format(time.RFC3339) || f[4] != fmt.Sprint(fi.Size()) {
+		t.Errorf("--status: exit %d, output %q; want version, data version, 2 entries, CSV date and size", code, out)
+	}
+}
+
+func TestListenAddrHost(t *testing.T) {
+	tests := []struct {
+		host, listen, want string
+		wantErr            bool
+	}{
+		{"", "", ":9922", false},
+		{"127.0.0.1", "", "127.0.0.1:9922", false},
+		{"::1", "", "[::1]:9922", false},
+		{"[::1]", "", "[::1]:9922", false},
+		{"api.example.com", "", "api.example.com:9922", false},
+		{"bad_host", "", "", true},
+		{"-bad.example", "", "", true},
+		{"127.0.0.1", "0.0.0.0:8080", "0.0.0.0:8080", false}, // --listen manda
+	}
+	defer func() { bindHost, listen = "", "" }()
+	for _, tt := range tests {
+		bindHost, listen = tt.host, tt.listen
+		got, err := listenAddr()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("--host %q --listen %q: %q, %v; want %q (error %v)", tt.host, tt.listen, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for url, ok := range map[string]bool{
+		"https://dgii.gov.do/RNC_CONTRIBUYENTES.zip": true,
+		"http://mirror.local:8080/rnc.zip":           true,
+		"ftp://mirror.local/rnc.zip":                 false,
+		"/tmp/rnc.zip":                               false,
+		"https://":                                   false,
+	} {
+		if err := checkURL(url); (err == nil) != ok {
+			t.Errorf("checkURL(%q) = %v, want ok %v", url, err, ok)
+		}
+	}
+}
+
+func TestCheckRNCETag(t *testing.T) {
+	useTestCSV(t, testRows)
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	newHTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/checkrnc/132138279", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || rec.Header().Get("X-Data-Version") == "" {
+		t.Fatalf("checkrnc = %d, ETag %q; want 200 with ETag and X-Data-Version", rec.Code, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/checkrnc/101010632", nil)
+	req.Header.Set("If-None-Match", `"otra", W/`+etag)
+	rec = httptest.NewRecorder()
+	newHTTPHandler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("If-None-Match with the current ETag = %d %q, want 304 without body", rec.Code, rec.Body.String())
+	}
+}
+
+// TestPathParams comprueba que las rutas con un parámetro lo decodifican,
+// toleran una barra final y rechazan con 400 el valor vacío o en blanco y
+// los segmentos de más.
+func TestPathParams(t *testing.T) {
+	loadTestCSV(t, testRows)
+	tests := []struct {
+		path string
+		code int
+		err  string
+	}{
+		{"/api/checkrnc/132138279", http.StatusOK, ""},
+		{"/api/checkrnc/132138279/", http.StatusOK, ""},
+		{"/api/checkrnc/1%2D32%2D13827%2D9", http.StatusOK, ""},
+		{"/api/checkrnc/132138279/extra", http.StatusBadRequest, `malformed path "/api/checkrnc/132138279/extra": expected /api/checkrnc/{RNC}`},
+		{"/api/checkrnc/", http.StatusBadRequest, "RNC not provided"},
+		{"/api/checkrnc/%20%20", http.StatusBadRequest, "RNC not provided"},
+		{"/api/searchname/ferreteria", http.StatusOK, ""},
+		{"/api/searchname/%20ferreteria%20", http.StatusOK, ""},
+		{"/api/searchname/ferreteria/", http.StatusOK, ""},
+		{"/api/searchname/A%2FB", http.StatusOK, ""}, // la barra codificada es parte del nombre
+		{"/api/searchname/ferreteria/extra", http.StatusBadRequest, `malformed path "/api/searchname/ferreteria/extra": expected /api/searchname/{Query}`},
+		{"/api/searchname/%20", http.StatusBadRequest, "Query not provided"},
+		{"/api/validate/132138279/x", http.StatusBadRequest, `malformed path "/api/validate/132138279/x": expected /api/validate/{Number}`},
+		{"/api/suggest/%20", http.StatusBadRequest, "Prefix not provided"},
+		{"/api/checkcedula/", http.StatusBadRequest, "Cedula not provided"},
+	}
+	for _, tt := range tests {
+		code, body := get(t, tt.path)
+		var got struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal([]byte(body), &got)
+		if code != tt.code || got.Error != tt.err {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, code, got.Error, tt.code, tt.err)
+		}
+	}
+}
+
+func TestNotFoundAndMethods(t *testing.T) {
+	useTestCSV(t, testRows)
+	rec := httptest.NewRecorder()
+	newHTTPHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/nope", nil))
+	if rec.Code != http.StatusNotFound || strings.TrimSpace(rec.Body.String()) != `{"error":"not found","path":"/api/nope"}` {
+		t.Errorf("unknown route = %d %s, want a JSON 404", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		method, path, allow string
+	}{
+		{http.MethodPost, "/api/checkrnc/132138279", "GET, HEAD"},
+		{http.MethodDelete, "/api/searchname/ferreteria", "GET, HEAD"},
+		{http.MethodGet, "/api/checkrnc/batch", "POST"},
+		{http.MethodGet, "/api/reload", "POST"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		newHTTPHandler().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s = %d, Allow %q; want 405 with Allow %q", tt.method, tt.path, rec.Code, rec.Header().Get("Allow"), tt.allow)
+		}
+	}
+}
+
+// TestEnsureIndexRetry comprueba que una carga fallida no se recuerda: cuando
+// el CSV aparece, la siguiente llamada carga el índice.
+func TestEnsureIndexRetry(t *testing.T) {
+	path := useTestCSV(t, testRows)
+	if err := flag.Set("csv", filepath.Dir(path)); err != nil { // un directorio no se puede leer
+		t.Fatal(err)
+	}
+	if err := ensureIndex(context.Background()); err == nil {
+		t.Fatal("ensureIndex with an unreadable CSV succeeded")
+	}
+	if err := flag.Set("csv", path); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- ensureIndex(context.Background())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("ensureIndex after the CSV appeared: %v", err)
+		}
+	}
+	if idx := currentIndex(); idx == nil || idx.Len() != 2 {
+		t.Errorf("index not published after the retry")
+	}
+}
+
+func TestCLIErrors(t *testing.T) {
+	csv := writeTestCSV(t, testRows)
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{"encontrado", []string{"-csv", csv, "132138279"}, 0, `"socialName": "FERRETERIA AMERICANA SRL"`},
+		{"no existe", []string{"-csv", csv, "131000012"}, exitNotFound, `"error": "This RNC does not exist"`},
+		{"CSV ilegible", []string{"-csv", t.TempDir(), "132138279"}, exitIndexError, `"error": "Error loading index: `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, code := runMain(t, "", tt.args...)
+			if code != tt.code || !strings.Contains(out, tt.want) {
+				t.Errorf("exit %d, output:\n%s\nwant exit %d and %s", code, out, tt.code, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	csv := writeTestCSV(t, testRows)
+	tests := []struct {
+		format, rnc, want string
+	}{
+		{"csv", "132138279", "132138279,FERRETERIA AMERICANA SRL,FERRETODO,ACTIVO\n"},
+		{"plain", "132138279", "132138279\tFERRETERIA AMERICANA SRL\tFERRETODO\tACTIVO\n"},
+		{"csv", "131000012", "error,This RNC does not exist\n"},
+		{"plain", "131000012", "error\tThis RNC does not exist\n"},
+	}
+	for _, tt := range tests {
+		out, _ := runMain(t, "", "-csv", csv, "-output", tt.format, tt.rnc)
+		if out != tt.want {
+			t.Errorf("--output %s %s = %q, want %q", tt.format, tt.rnc, out, tt.want)
+		}
+	}
+	if _, code := runMain(t, "", "-csv", csv, "-output", "xml", "132138279"); code == 0 {
+		t.Error("--output xml accepted")
+	}
+}
+
+// TestFullRecord comprueba ?full=1 con y sin --full-index y la salida de
+// --full en la CLI.
+func TestFullRecord(t *testing.T) {
+	loadTestCSV(t, testRows)
+	if code, body := get(t, "/api/checkrnc/132138279?full=1"); code != http.StatusBadRequest || !strings.Contains(body, "full records are not available") {
+		t.Errorf("?full=1 without --full-index = %d %s, want 400", code, body)
+	}
+
+	fullIndex = true
+	resetIndex()
+	t.Cleanup(func() { fullIndex = false })
+	if err := ensureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	code, body := get(t, "/api/checkrnc/132138279?full=1")
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(body), &raw); err != nil || code != http.StatusOK || raw["fecha_de_inicio"] != "01/01/2000" {
+		t.Errorf("?full=1 = %d %s, want every column", code, body)
+	}
+	if code, _ := get(t, "/api/checkrnc/131000012?full=1"); code != http.StatusNotFound {
+		t.Errorf("?full=1 of a missing RNC = %d, want 404", code)
+	}
+
+	out, code := runMain(t, "", "-csv", writeTestCSV(t, testRows), "-full", "-output", "csv", "132138279")
+	if code != 0 || !strings.HasPrefix(out, "rnc,132138279\nrazon_social,FERRETERIA AMERICANA SRL\n") || !strings.Contains(out, "\nfecha_de_inicio,01/01/2000\n") {
+		t.Errorf("--full: exit %d, output:\n%s", code, out)
+	}
+}
+
+func TestValidateFlag(t *testing.T) {
+	good := writeTestCSV(t, testRows+"401506254\n")
+	empty := writeTestCSV(t, "")
+	tests := []struct {
+		name string
+		path string
+		code int
+		want string
+	}{
+		{"válido", good, 0, good + "\ttrue\t3\t2\t1\t0\n"},
+		{"sin entradas", empty, exitIndexError, empty + "\tfalse\t0\t0\t0\t0\n"},
+		{"no existe", filepath.Join(t.TempDir(), "missing.csv"), exitIndexError, "\tfalse\t0\t0\t0\t0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, code := runMain(t, "", "-validate", tt.path, "-output", "plain")
+			if code != tt.code || !strings.HasSuffix(out, tt.want) {
+				t.Errorf("--validate: exit %d, output %q; want exit %d and %q", code, out, tt.code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCedulaCacheSizeFlag(t *testing.T) {
+	if _, stderr, code := runMainStderr(t, "", "-cedula-cache-size", "-1", "132138279"); code == 0 || !strings.Contains(stderr, "--cedula-cache-size") {
+		t.Errorf("--cedula-cache-size -1: exit %d, stderr %q; want an error", code, stderr)
+	}
+}
+
+func TestVersionFlag(t *testing.T) {
+	// --version no valida el resto ni toca el CSV
+	out, code := runMain(t, "", "-version", "-csv", filepath.Join(t.TempDir(), "no-existe.csv"), "-output", "xml")
+	if code != 0 || out != "rncs dev (commit unknown, built unknown)\n" {
+		t.Errorf("--version: exit %d, output %q", code, out)
+	}
+}
+
+func TestTimeoutFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{"-read-timeout", "0"}, false},
+		{[]string{"-write-timeout", "-1s"}, false},
+		{[]string{"-idle-timeout", "0s"}, false},
+		{[]string{"-export-timeout", "0"}, false},
+		{[]string{"-cedula-timeout", "-5s"}, false},
+		{[]string{"-read-timeout", "1ms", "-reload-timeout", "1h"}, true},
+	}
+	csv := writeTestCSV(t, testRows)
+	for _, tt := range tests {
+		_, stderr, code := runMainStderr(t, "", append(tt.args, "-csv", csv, "132138279")...)
+		if (code == 0) != tt.ok || (!tt.ok && !strings.Contains(stderr, "must be a positive duration")) {
+			t.Errorf("%v: exit %d, stderr %q; want ok %v", tt.args, code, stderr, tt.ok)
+		}
+	}
+}
+
+func TestRateFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{"-rate", "5", "-burst", "0"}, false},
+		{[]string{"-rate", "-1"}, false},
+		{[]string{"-rate", "0", "-burst", "0"}, true}, // sin límite el burst no importa
+		{[]string{"-rate", "5", "-burst", "1"}, true},
+	}
+	csv := writeTestCSV(t, testRows)
+	for _, tt := range tests {
+		_, stderr, code := runMainStderr(t, "", append(tt.args, "-csv", csv, "132138279")...)
+		if (code == 0) != tt.ok {
+			t.Errorf("%v: exit %d, stderr %q; want ok %v", tt.args, code, stderr, tt.ok)
+		}
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	csv := writeTestCSV(t, testRows)
+	if _, stderr, _ := runMainStderr(t, "", "-csv", csv, "132138279"); !strings.Contains(stderr, "Index loaded") {
+		t.Errorf("without --quiet, stderr = %q, want the index log", stderr)
+	}
+	out, stderr, _ := runMainStderr(t, "", "-csv", csv, "-quiet", "132138279")
+	if stderr != "" {
+		t.Errorf("--quiet: stderr = %q, want empty", stderr)
+	}
+	if !strings.Contains(out, "132138279") {
+		t.Errorf("--quiet: stdout = %q, want the result", out)
+	}
+}
+
+// TestServeShutdown comprueba que al cancelar el contexto el servidor deja
+// de aceptar conexiones pero termina las peticiones en curso.
+func TestServeShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	started := make(chan struct{})
+	srv := &http.Server{Addr: addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, nil) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	res := make(chan result, 1)
+	go func() {
+		for {
+			resp, err := http.Get("http://" + addr)
+			if err != nil && ctx.Err() == nil {
+				time.Sleep(10 * time.Millisecond) // aún no escucha
+				continue
+			}
+			if err != nil {
+				res <- result{err: err}
+				return
+			}
+			b, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			res <- result{string(b), err}
+			return
+		}
+	}()
+
+	<-started
+	cancel()
+	if r := <-res; r.err != nil || r.body != "done" {
+		t.Errorf("in-flight request: body %q, err %v; want done", r.body, r.err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("serve: %v", err)
+	}
+	if _, err := http.Get("http://" + addr); err == nil {
+		t.Error("server still accepting connections after shutdown")
+	}
+}
+
+// TestLoadIndexBadCache comprueba que un caché corrupto o viejo se ignora:
+// el índice se construye desde el CSV y el caché se regenera.
+func TestLoadIndexBadCache(t *testing.T) {
+	path := useTestCSV(t, testRows)
+	cache := indexCachePath()
+	if err := os.WriteFile(cache, []byte("not a gob"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := loadIndex()
+	if err != nil {
+		t.Fatalf("loadIndex with a corrupt cache: %v", err)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", idx.Len())
+	}
+	if _, err := rnc.LoadCache(path, cache); err != nil {
+		t.Errorf("cache not rewritten: %v", err)
+	}
+
+	// un CSV nuevo deja viejo el caché recién escrito
+	if err := os.WriteFile(path, []byte(testHeader+"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if idx, err = loadIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 1 {
+		t.Errorf("after the CSV changed: Len() = %d, want 1", idx.Len())
+	}
+}
+
+// TestREPL pasa por --repl líneas vacías, válidas, con guiones, inexistentes
+// y mal formadas: una línea de salida por RNC y en el mismo orden.
+func TestREPL(t *testing.T) {
+	loadTestCSV(t, testRows)
+	replMode = true
+	t.Cleanup(func() { replMode, outputFormat = false, "json" })
+
+	in := "132138279\n\n  1-01-01063-2 \n131000012\n132138278\n13213827X\n"
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"json", []string{
+			`{"rnc":"132138279","socialName":"FERRETERIA AMERICANA SRL","comercialName":"FERRETODO","status":"ACTIVO","economicActivity":"COMERCIO","paymentRegime":"NORMAL"}`,
+			`{"rnc":"101010632","socialName":"CONSTRUCTORA DEL CARIBE SA","comercialName":"CARIBE","status":"SUSPENDIDO","economicActivity":"CONSTRUCCION","paymentRegime":"NORMAL"}`,
+			`{"error":"This RNC does not exist"}`,
+			`{"error":"invalid RNC format"}`,
+			`{"error":"RNC must contain only digits"}`,
+		}},
+		{"plain", []string{
+			"132138279\tFERRETERIA AMERICANA SRL\tFERRETODO\tACTIVO",
+			"101010632\tCONSTRUCTORA DEL CARIBE SA\tCARIBE\tSUSPENDIDO",
+			"error\tThis RNC does not exist",
+			"error\tinvalid RNC format",
+			"error\tRNC must contain only digits",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputFormat = tt.format
+			var out strings.Builder
+			if code := runREPL(strings.NewReader(in), &out); code != 0 {
+				t.Fatalf("exit code %d", code)
+			}
+			got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if len(got) != len(tt.want) {
+				t.Fatalf("%d output lines for 5 RNCs:\n%s", len(got), out.String())
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("line %d = %s\nwant       %s", i+1, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestPreloadREPLFlags comprueba que --preload deja el snapshot junto al CSV
+// (y responde el RNC si se pasa) y que --repl rechaza las combinaciones sin
+// sentido.
+func TestPreloadREPLFlags(t *testing.T) {
+	path := writeTestCSV(t, testRows)
+	idx := strings.TrimSuffix(path, ".csv") + ".idx"
+	if out, code := runMain(t, "", "-csv", path, "-preload"); code != 0 || strings.TrimSpace(out) != "" {
+		t.Fatalf("--preload: exit %d, output %q; want 0 and no output", code, out)
+	}
+	if _, err := os.Stat(idx); err != nil {
+		t.Errorf("--preload left no snapshot: %v", err)
+	}
+	if out, code := runMain(t, "", "-csv", path, "-preload", "-output", "plain", "132138279"); code != 0 || !strings.HasPrefix(out, "132138279\t") {
+		t.Errorf("--preload with an RNC: exit %d, output %q", code, out)
+	}
+
+	out, code := runMain(t, "132138279\n131000012\n", "-csv", path, "-repl", "-output", "plain")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); code != 0 || len(lines) != 2 {
+		t.Errorf("--repl: exit %d, output %q; want two lines", code, out)
+	}
+	for _, args := range [][]string{{"-repl", "-status"}, {"-repl", "-full"}, {"-repl", "132138279"}} {
+		if _, code := runMain(t, "", append([]string{"-csv", path}, args...)...); code != 1 {
+			t.Errorf("%v: exit %d, want 1", args, code)
+		}
+	}
+}
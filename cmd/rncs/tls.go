@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+/* ---------- TLS ---------- */
+
+// certificate es el par vigente de --tls-cert/--tls-key. Se sirve con
+// GetCertificate para poder cambiarlo (SIGHUP) sin reiniciar el servidor.
+var certificate atomic.Pointer[tls.Certificate]
+
+// tlsVersions son los valores aceptados por --tls-min-version.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// checkTLSFiles exige --tls-cert y --tls-key juntos, valida
+// --tls-min-version y carga el par, para fallar al arrancar y no al primer
+// handshake.
+func checkTLSFiles() error {
+	if tlsCert == "" && tlsKey == "" {
+		return nil
+	}
+	if tlsCert == "" || tlsKey == "" {
+		return errors.New("--tls-cert and --tls-key must be set together")
+	}
+	if _, ok := tlsVersions[tlsMinVersion]; !ok {
+		return fmt.Errorf("invalid --tls-min-version %q (use 1.0, 1.1, 1.2 or 1.3)", tlsMinVersion)
+	}
+	return reloadCertificate()
+}
+
+// reloadCertificate vuelve a leer --tls-cert y --tls-key. Si fallan, el
+// certificado anterior sigue en uso.
+func reloadCertificate() error {
+	cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
+	if err != nil {
+		return fmt.Errorf("cannot load certificate %q / key %q: %w", tlsCert, tlsKey, err)
+	}
+	certificate.Store(&cert)
+	slog.Info("TLS certificate loaded", "cert", tlsCert)
+	return nil
+}
+
+// tlsConfig devuelve la configuración del servidor HTTPS, o nil sin TLS.
+func tlsConfig() *tls.Config {
+	if tlsCert == "" {
+		return nil
+	}
+	return &tls.Config{
+		MinVersion: tlsVersions[tlsMinVersion],
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certificate.Load(), nil
+		},
+	}
+}
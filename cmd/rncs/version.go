@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+/* ---------- Versión ---------- */
+
+// Datos del build, inyectados con
+//
+//	go build -ldflags "-X main.version=1.3.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// versionString es la línea que imprime --version.
+func versionString() string {
+	return fmt.Sprintf("rncs %s (commit %s, built %s)", version, commit, buildDate)
+}
@@ -0,0 +1,377 @@
+package rnc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+/* ---------- Índice en disco (bbolt) ---------- */
+
+// boltFormat cambia cuando cambia el contenido de la base (como cacheVersion;
+// 2: cuenta de duplicados; 3: separador y filas de cabecera; 4: codificación;
+// 5: reparación de UTF-8).
+const boltFormat = 5
+
+var (
+	bucketEmpresas = []byte("empresas") // RNC normalizado -> Empresa en JSON
+	bucketMeta     = []byte("meta")
+	keyMeta        = []byte("meta")
+)
+
+// diskMeta describe una base en disco (bbolt o SQLite): el CSV del que salió
+// y su contenido.
+type diskMeta struct {
+	Format     int
+	CSVModTime time.Time
+	CSVSize    int64
+	Entries    int
+	Duplicates int    // filas con un RNC ya escrito, como Index.Duplicates
+	Version    string // mismo hash que Index.DataVersion
+
+	CSV csvFormat // opciones de lectura del CSV
+}
+
+// BoltStore sirve las consultas por RNC desde una base bbolt en disco, para
+// equipos donde el padrón no cabe en memoria. Solo guarda lo necesario para
+// Lookup: las búsquedas por nombre, estado o prefijo requieren un Index. Es
+// seguro para uso concurrente.
+type BoltStore struct {
+	csvPath, dbPath string
+	opts            Options // solo el formato del CSV (csvFormat)
+
+	mu       sync.RWMutex // Replace espera a las lecturas antes de cerrar la base vieja
+	db       *bolt.DB
+	meta     diskMeta
+	loadedAt time.Time
+}
+
+// OpenBolt abre la base dbPath si se generó a partir de la versión actual de
+// csvPath; si no existe o está desactualizada la reconstruye desde el CSV,
+// fila a fila y sin cargarlo en memoria.
+func OpenBolt(csvPath, dbPath string) (*BoltStore, error) {
+	return OpenBoltOptions(csvPath, dbPath, Options{})
+}
+
+// OpenBoltOptions es como OpenBolt para un CSV que se lee con opts. Solo se
+// usan Delimiter, HeaderRows y Encoding: la base no guarda filas completas
+// ni campos extra.
+func OpenBoltOptions(csvPath, dbPath string, opts Options) (*BoltStore, error) {
+	st, err := os.Stat(csvPath)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.format().options()
+	s := &BoltStore{csvPath: csvPath, dbPath: dbPath, opts: opts}
+	db, meta, err := openBoltDB(dbPath)
+	if err == nil && meta.matches(boltFormat, st, opts) {
+		slog.Info("Index opened from disk", "path", dbPath, "entries", meta.Entries)
+		s.db, s.meta, s.loadedAt = db, meta, time.Now()
+		return s, nil
+	}
+	if db != nil {
+		db.Close()
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Rebuilding on-disk index", "path", dbPath, "err", err)
+	}
+	if err := s.rebuild(context.Background(), csvPath, 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload vuelve a construir la base desde el CSV de origen. Si falla, la base
+// anterior sigue en uso.
+func (s *BoltStore) Reload() error {
+	return s.rebuild(context.Background(), s.csvPath, 0)
+}
+
+// ReloadContext es Reload con un ctx que interrumpe la construcción.
+func (s *BoltStore) ReloadContext(ctx context.Context) error {
+	return s.rebuild(ctx, s.csvPath, 0)
+}
+
+// Replace es como Index.Replace: construye una base nueva desde path y, si
+// tiene al menos minEntries entradas, mueve path sobre el CSV de origen y
+// empieza a usarla.
+func (s *BoltStore) Replace(path string, minEntries int) error {
+	return s.rebuild(context.Background(), path, minEntries)
+}
+
+// ReplaceContext es Replace con un ctx que interrumpe la construcción; si se
+// cancela, la base y el CSV actuales no se tocan.
+func (s *BoltStore) ReplaceContext(ctx context.Context, path string, minEntries int) error {
+	return s.rebuild(ctx, path, minEntries)
+}
+
+// rebuild construye la base desde path en un archivo temporal y la pone en
+// lugar de dbPath (y path en lugar de csvPath, si son distintos).
+func (s *BoltStore) rebuild(ctx context.Context, path string, minEntries int) error {
+	src, err := os.Stat(path) // el rename conserva fecha y tamaño
+	if err != nil {
+		return err
+	}
+	tmp, meta, err := buildBolt(ctx, path, filepath.Dir(s.dbPath), src, s.opts)
+	if err != nil {
+		return fmt.Errorf("error parsing new CSV: %w", err)
+	}
+	defer os.Remove(tmp) // no existe si el rename salió bien
+	if meta.Entries < minEntries {
+		return fmt.Errorf("%w: %d, expected at least %d", ErrTooFewEntries, meta.Entries, minEntries)
+	}
+	if path != s.csvPath {
+		if err := os.Rename(path, s.csvPath); err != nil {
+			return err
+		}
+	}
+	// la base vieja sigue abierta (y legible) aunque se reemplace el archivo
+	if err := os.Rename(tmp, s.dbPath); err != nil {
+		return err
+	}
+	db, meta, err := openBoltDB(s.dbPath)
+	if err != nil {
+		if db != nil {
+			db.Close()
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.db
+	s.db, s.meta, s.loadedAt = db, meta, time.Now()
+	s.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	slog.Info("Index written to disk", "path", s.dbPath, "entries", meta.Entries)
+	return nil
+}
+
+// Lookup busca un contribuyente por RNC o cédula. Acepta los formatos con
+// guiones o espacios y tolera la ausencia o sobra del cero inicial.
+func (s *BoltStore) Lookup(rnc string) (Empresa, bool) {
+	norm, ok := Normalize(rnc)
+	if !ok {
+		return Empresa{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var emp Empresa
+	found := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketEmpresas)
+		for _, k := range variants(norm) {
+			if v := b.Get([]byte(k)); v != nil {
+				found = true
+				return json.Unmarshal(v, &emp)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Error("On-disk index lookup failed", "rnc", rnc, "err", err)
+		return Empresa{}, false
+	}
+	return emp, found
+}
+
+// Len devuelve el número de entradas de la base.
+func (s *BoltStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Entries
+}
+
+// LoadedAt devuelve el momento en que se abrió la base actual.
+func (s *BoltStore) LoadedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadedAt
+}
+
+// SourceInfo devuelve la fecha de modificación y el tamaño del CSV del que
+// salió la base actual.
+func (s *BoltStore) SourceInfo() (modTime time.Time, size int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.CSVModTime, s.meta.CSVSize
+}
+
+// DataVersion identifica el contenido de la base; coincide con el de un
+// Index cargado del mismo CSV.
+func (s *BoltStore) DataVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Version
+}
+
+// Duplicates devuelve cuántas filas del CSV repetían un RNC anterior.
+func (s *BoltStore) Duplicates() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Duplicates
+}
+
+// Close cierra la base.
+func (s *BoltStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Close()
+}
+
+// matches dice si la base, de formato format, salió del CSV st leído con opts.
+func (m diskMeta) matches(format int, st os.FileInfo, opts Options) bool {
+	return m.Format == format && m.CSVModTime.Equal(st.ModTime()) && m.CSVSize == st.Size() &&
+		m.CSV == opts.format()
+}
+
+// openBoltDB abre dbPath en solo lectura y lee sus metadatos.
+func openBoltDB(dbPath string) (*bolt.DB, diskMeta, error) {
+	var meta diskMeta
+	db, err := bolt.Open(dbPath, 0o600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return nil, meta, err
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketMeta)
+		if b == nil || tx.Bucket(bucketEmpresas) == nil {
+			return errors.New("on-disk index is incomplete")
+		}
+		return json.Unmarshal(b.Get(keyMeta), &meta)
+	})
+	return db, meta, err
+}
+
+// buildBolt escribe el CSV de path en una base nueva dentro de dir y
+// devuelve su ruta. El llamador la mueve a su sitio o la borra.
+func buildBolt(ctx context.Context, path, dir string, src os.FileInfo, opts Options) (string, diskMeta, error) {
+	b := newBoltBuilder(dir)
+	defer b.close()
+	if err := readCSV(ctx, func() (io.ReadCloser, error) { return openCSV(path) }, opts, b); err != nil {
+		os.Remove(b.path)
+		return "", diskMeta{}, err
+	}
+	meta, err := b.finish(src, opts)
+	if err != nil {
+		os.Remove(b.path)
+		return "", diskMeta{}, err
+	}
+	if meta.Duplicates > 0 {
+		slog.Warn("CSV has duplicate RNCs, keeping the last row of each", "duplicates", meta.Duplicates)
+	}
+	return b.path, meta, nil
+}
+
+// boltBuilder es el rowSink que escribe una base nueva, un tramo por
+// transacción.
+type boltBuilder struct {
+	path       string
+	db         *bolt.DB
+	err        error // si no se pudo crear la base; add lo devuelve
+	duplicates int
+}
+
+func newBoltBuilder(dir string) *boltBuilder {
+	b := &boltBuilder{}
+	f, err := os.CreateTemp(dir, "rncs-*.db.tmp")
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.path = f.Name()
+	f.Close()
+	// sin fsync por transacción: finish sincroniza una vez al terminar
+	if b.db, b.err = bolt.Open(b.path, 0o600, &bolt.Options{NoSync: true}); b.err != nil {
+		return b
+	}
+	b.err = b.db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucket(bucketEmpresas); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(bucketMeta)
+		return err
+	})
+	return b
+}
+
+func (b *boltBuilder) header([]string) {}
+
+func (b *boltBuilder) skipped(int) {}
+
+func (b *boltBuilder) add(rows []parsedRow) error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(bucketEmpresas)
+		for _, p := range rows {
+			v, err := json.Marshal(p.emp)
+			if err != nil {
+				return err
+			}
+			if bk.Get([]byte(p.emp.RNC)) != nil {
+				b.duplicates++
+			}
+			if err := bk.Put([]byte(p.emp.RNC), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// finish cuenta las entradas, calcula la versión de los datos (en orden de
+// RNC, como Index) y guarda los metadatos.
+func (b *boltBuilder) finish(src os.FileInfo, opts Options) (diskMeta, error) {
+	meta := diskMeta{Format: boltFormat, CSVModTime: src.ModTime(), CSVSize: src.Size(), Duplicates: b.duplicates,
+		CSV: opts.format()}
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		h := fnv.New64a()
+		err := tx.Bucket(bucketEmpresas).ForEach(func(_, v []byte) error {
+			var e Empresa
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			hashEmpresa(h, e)
+			meta.Entries++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		meta.Version = strconv.FormatUint(h.Sum64(), 16)
+		v, err := json.Marshal(meta)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketMeta).Put(keyMeta, v)
+	})
+	if err == nil {
+		err = b.db.Sync()
+	}
+	if cerr := b.close(); err == nil {
+		err = cerr
+	}
+	return meta, err
+}
+
+func (b *boltBuilder) close() error {
+	if b.db == nil {
+		return nil
+	}
+	db := b.db
+	b.db = nil
+	return db.Close()
+}
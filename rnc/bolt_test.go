@@ -0,0 +1,74 @@
+package rnc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestBoltStore comprueba que la base en disco responde como un Index del
+// mismo CSV, que se reutiliza mientras el CSV no cambie y que Replace
+// rechaza un CSV con menos entradas de las pedidas.
+func TestBoltStore(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "rncs.csv")
+	dbPath := filepath.Join(dir, "rncs.db")
+	const one = "132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	const rows = one + "00113918205,JUAN PEREZ,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	if err := os.WriteFile(csvPath, []byte(testHeader+rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenBolt(csvPath, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := newTestIndex(t, rows)
+	for _, q := range []string{"1-32-13827-9", "001-1391820-5", "0113918205"} {
+		got, ok := s.Lookup(q)
+		want, _ := idx.Lookup(q)
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("Lookup(%q) = %+v, %v; want %+v", q, got, ok, want)
+		}
+	}
+	if _, ok := s.Lookup("131000012"); ok {
+		t.Error("Lookup(131000012) found a record")
+	}
+	if s.Len() != 2 || s.DataVersion() != idx.DataVersion() {
+		t.Errorf("Len = %d, DataVersion = %s; want 2 and %s", s.Len(), s.DataVersion(), idx.DataVersion())
+	}
+	s.Close()
+
+	// el CSV no cambió: se abre la base existente sin reconstruirla
+	before, _ := os.Stat(dbPath)
+	s, err = OpenBolt(csvPath, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { s.Close() }()
+	if after, _ := os.Stat(dbPath); !after.ModTime().Equal(before.ModTime()) {
+		t.Error("database rebuilt although the CSV did not change")
+	}
+
+	small := filepath.Join(dir, "new.csv")
+	if err := os.WriteFile(small, []byte(testHeader+one), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Replace(small, 2); err == nil {
+		t.Error("Replace accepted a CSV with fewer entries than required")
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len after a rejected Replace = %d, want 2", s.Len())
+	}
+
+	// con otro separador la base se reconstruye
+	s.Close()
+	before, _ = os.Stat(dbPath)
+	if s, err = OpenBoltOptions(csvPath, dbPath, Options{Delimiter: ';'}); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.Stat(dbPath); after.ModTime().Equal(before.ModTime()) || s.Len() != 0 {
+		t.Errorf("database not rebuilt for another delimiter (%d entries)", s.Len())
+	}
+}
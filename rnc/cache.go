@@ -0,0 +1,197 @@
+package rnc
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion cambia cuando cambia el formato de indexCache o la forma de
+// construir las entradas (2: RNC normalizados; 3: filas completas; 4:
+// Options.Fields; 5: separador y filas de cabecera; 6: hash del CSV y
+// nombres normalizados; 7: codificación; 8: reparación de UTF-8; 9: una
+// entrada por RNC repetido).
+const cacheVersion = 9
+
+// indexCache es el contenido serializado de un índice, con el hash del CSV
+// de origen para saber si sigue vigente.
+type indexCache struct {
+	Version   int
+	CSVSHA256 string    // del archivo tal como está en disco (comprimido o no)
+	Entries   []Empresa // en el orden del CSV
+
+	// Names es el buffer del índice de nombres (nombres ya pasados por
+	// Fold, en el orden de Entries): cargarlo evita el Fold de cada nombre,
+	// la mayor parte del tiempo de carga.
+	Names string
+
+	DataVersion string // la de Index.DataVersion, que no hace falta recalcular
+	Duplicates  int    // la de Index.Duplicates: Entries ya no los tiene
+
+	// Solo si el índice tenía Options.Full; Rows va en paralelo a Entries.
+	Columns []string
+	Rows    [][]string
+
+	// opciones de lectura con que se construyeron las entradas
+	Fields map[string]int
+	Format csvFormat
+}
+
+var errStaleCache = errors.New("index cache is stale")
+
+// LoadCache carga un índice desde el caché en cachePath si fue generado a
+// partir del contenido actual de csvPath (mismo SHA-256; la fecha del
+// archivo no importa). Devuelve un error si el caché no existe, está
+// corrupto o el CSV cambió desde que se guardó.
+func LoadCache(csvPath, cachePath string) (*Index, error) {
+	return LoadCacheOptions(csvPath, cachePath, Options{})
+}
+
+// LoadCacheOptions es como LoadCache para un índice con opts. Un caché
+// guardado sin filas completas no sirve para Options.Full.
+func LoadCacheOptions(csvPath, cachePath string, opts Options) (*Index, error) {
+	st, err := os.Stat(csvPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(cachePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// el archivo empieza con el SHA-256 del resto: gob no detecta un byte
+	// cambiado dentro de un string
+	sum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(f, sum); err != nil {
+		return nil, fmt.Errorf("corrupt index cache: %w", err)
+	}
+	h := sha256.New()
+	body := io.TeeReader(f, h)
+	var c indexCache
+	if err := gob.NewDecoder(body).Decode(&c); err != nil {
+		return nil, fmt.Errorf("corrupt index cache: %w", err)
+	}
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(h.Sum(nil), sum) {
+		return nil, errors.New("corrupt index cache: checksum mismatch")
+	}
+	if c.Version != cacheVersion {
+		return nil, errStaleCache
+	}
+	if (opts.Full && c.Rows == nil) || !maps.Equal(c.Fields, opts.Fields) ||
+		c.Format != opts.format() {
+		return nil, errStaleCache
+	}
+	if sum, err := fileSHA256(csvPath); err != nil {
+		return nil, err
+	} else if sum != c.CSVSHA256 {
+		return nil, errStaleCache
+	}
+
+	if opts.Strict && c.Duplicates > 0 {
+		return nil, fmt.Errorf("%w: %d rows", ErrDuplicateRNC, c.Duplicates)
+	}
+
+	t := &tables{byRNC: make(map[string]entry, len(c.Entries)), duplicates: c.Duplicates, version: c.DataVersion}
+	if opts.Full {
+		t.columns, t.rows = c.Columns, make(map[string][]string, len(c.Rows))
+	}
+	rncs := make([]string, len(c.Entries))
+	for i, emp := range c.Entries {
+		rncs[i] = t.put(emp)
+		if t.rows != nil {
+			t.rows[rncs[i]] = t.copyRow(c.Rows[i])
+		}
+	}
+	var ok bool
+	if t.byName, ok = nameIndexFrom(c.Names, rncs); !ok {
+		return nil, fmt.Errorf("corrupt index cache: names do not match entries")
+	}
+	return newIndex(csvPath, opts, t, st), nil
+}
+
+// SaveCache guarda el índice en cachePath junto con el SHA-256 del CSV de
+// origen, para que LoadCache pueda evitar el parseo en el próximo arranque.
+func (x *Index) SaveCache(cachePath string) error {
+	if x.path == "" {
+		return errors.New("index has no source file to cache")
+	}
+	s := x.data.Load()
+	sum, err := fileSHA256(x.path)
+	if err != nil {
+		return err
+	}
+	c := indexCache{
+		Version:     cacheVersion,
+		CSVSHA256:   sum,
+		Entries:     make([]Empresa, 0, len(s.byName.rncs)),
+		Names:       s.byName.hay,
+		DataVersion: s.version,
+		Duplicates:  s.duplicates,
+		Fields:      x.opts.Fields,
+		Format:      x.opts.format(),
+	}
+	for _, k := range s.byName.rncs {
+		c.Entries = append(c.Entries, s.empresa(k))
+	}
+	if s.rows != nil {
+		c.Columns = s.columns
+		c.Rows = make([][]string, 0, len(c.Entries))
+		for _, k := range s.byName.rncs {
+			c.Rows = append(c.Rows, s.rows[k])
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), filepath.Base(cachePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	w := bufio.NewWriter(io.MultiWriter(tmp, h))
+	_, err = tmp.Write(make([]byte, sha256.Size)) // el SHA-256 va al final, en su lugar
+	if err == nil {
+		err = gob.NewEncoder(w).Encode(&c)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		_, err = tmp.WriteAt(h.Sum(nil), 0)
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath)
+}
+
+// fileSHA256 devuelve el SHA-256 en hexadecimal del contenido de path. Leer
+// el padrón completo toma décimas de segundo, frente a varios segundos de
+// parseo.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
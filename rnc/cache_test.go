@@ -0,0 +1,145 @@
+package rnc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIndexCache(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "rncs.csv")
+	cachePath := filepath.Join(dir, "rncs.idx")
+	writeCSV := func(rows string) {
+		t.Helper()
+		if err := os.WriteFile(csvPath, []byte(testHeader+rows), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeCSV("132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	idx, err := NewIndexFromCSV(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.SaveCache(cachePath); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("vigente", func(t *testing.T) {
+		cached, err := LoadCache(csvPath, cachePath)
+		if err != nil {
+			t.Fatalf("LoadCache: %v", err)
+		}
+		if emp, ok := cached.Lookup("132138279"); !ok || emp.SocialName != "FERRETERIA AMERICANA SRL" {
+			t.Errorf("Lookup = %+v, %v", emp, ok)
+		}
+		if res, total := cached.Search("ferreteria", 10, 0); total != 1 || len(res) != 1 {
+			t.Errorf("Search = %d results (total %d), want 1", len(res), total)
+		}
+	})
+
+	t.Run("otra fecha, mismo contenido", func(t *testing.T) {
+		later := time.Now().Add(time.Hour)
+		if err := os.Chtimes(csvPath, later, later); err != nil {
+			t.Fatal(err)
+		}
+		cached, err := LoadCache(csvPath, cachePath)
+		if err != nil {
+			t.Fatalf("LoadCache after touching the CSV: %v", err)
+		}
+		if cached.DataVersion() != idx.DataVersion() {
+			t.Errorf("DataVersion = %s, want %s", cached.DataVersion(), idx.DataVersion())
+		}
+	})
+
+	t.Run("filas completas", func(t *testing.T) {
+		if _, err := LoadCacheOptions(csvPath, cachePath, Options{Full: true}); !errors.Is(err, errStaleCache) {
+			t.Fatalf("LoadCacheOptions(Full) from a cache without rows = %v, want errStaleCache", err)
+		}
+		full, err := NewIndexFromCSVOptions(csvPath, Options{Full: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := full.SaveCache(cachePath); err != nil {
+			t.Fatal(err)
+		}
+		cached, err := LoadCacheOptions(csvPath, cachePath, Options{Full: true})
+		if err != nil {
+			t.Fatalf("LoadCacheOptions: %v", err)
+		}
+		if raw, ok, err := cached.Raw("132138279"); err != nil || !ok || raw["nombre_comercial"] != "FERRETODO" {
+			t.Errorf("Raw = %v, %v, %v", raw, ok, err)
+		}
+	})
+
+	t.Run("otros Fields", func(t *testing.T) {
+		opts := Options{Fields: map[string]int{"fecha_inicio": 4}}
+		if _, err := LoadCacheOptions(csvPath, cachePath, opts); !errors.Is(err, errStaleCache) {
+			t.Fatalf("LoadCacheOptions(Fields) from a cache without them = %v, want errStaleCache", err)
+		}
+		idx, err := NewIndexFromCSVOptions(csvPath, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.SaveCache(cachePath); err != nil {
+			t.Fatal(err)
+		}
+		cached, err := LoadCacheOptions(csvPath, cachePath, opts)
+		if err != nil {
+			t.Fatalf("LoadCacheOptions: %v", err)
+		}
+		if emp, _ := cached.Lookup("132138279"); emp.Extra["fecha_inicio"] != "01/01/2000" {
+			t.Errorf("Extra = %v", emp.Extra)
+		}
+	})
+
+	t.Run("otro formato de CSV", func(t *testing.T) {
+		for _, opts := range []Options{{Delimiter: ';'}, {HeaderRows: NoHeader}, {Encoding: EncodingWin1252}} {
+			if _, err := LoadCacheOptions(csvPath, cachePath, opts); !errors.Is(err, errStaleCache) {
+				t.Errorf("LoadCacheOptions(%+v) = %v, want errStaleCache", opts, err)
+			}
+		}
+	})
+
+	t.Run("CSV cambiado", func(t *testing.T) {
+		writeCSV("132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n" +
+			"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n")
+		if _, err := LoadCache(csvPath, cachePath); !errors.Is(err, errStaleCache) {
+			t.Errorf("LoadCache = %v, want errStaleCache", err)
+		}
+	})
+
+	t.Run("corrupto", func(t *testing.T) {
+		idx, err := NewIndexFromCSV(csvPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.SaveCache(cachePath); err != nil {
+			t.Fatal(err)
+		}
+		good, err := os.ReadFile(cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flipped := bytes.Clone(good)
+		flipped[len(flipped)-10] ^= 0xff
+		for name, data := range map[string][]byte{
+			"no es gob":      []byte("not a gob"),
+			"truncado":       good[:len(good)/2],
+			"byte cambiado":  flipped,
+			"sin encabezado": good[:sha256.Size-1],
+			"checksum en 0":  append(make([]byte, sha256.Size), good[sha256.Size:]...),
+		} {
+			if err := os.WriteFile(cachePath, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadCache(csvPath, cachePath); err == nil {
+				t.Errorf("%s: LoadCache of a corrupt cache succeeded", name)
+			}
+		}
+	})
+}
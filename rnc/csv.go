@@ -0,0 +1,211 @@
+package rnc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+/* ---------- Columnas del CSV ---------- */
+
+// columnMap indica la posición de cada campo en una fila; -1 = no existe.
+type columnMap struct {
+	rnc, razonSocial, nombreComercial, categoria, regimenPago, estado, actividad int
+}
+
+// positionalColumns es el mapeo histórico (0,1,2,4) usado cuando no hay cabecera.
+var positionalColumns = columnMap{
+	rnc:             0,
+	razonSocial:     1,
+	nombreComercial: 2,
+	categoria:       -1,
+	regimenPago:     -1,
+	estado:          4,
+	actividad:       -1,
+}
+
+// Nombres de cabecera (normalizados) aceptados para cada campo.
+var headerAliases = map[string]string{
+	"rnc":                   "rnc",
+	"rnc cedula":            "rnc",
+	"cedula rnc":            "rnc",
+	"razon social":          "razonSocial",
+	"nombre razon social":   "razonSocial",
+	"nombre o razon social": "razonSocial",
+	"nombre":                "razonSocial",
+	"nombre comercial":      "nombreComercial",
+	"categoria":             "categoria",
+	"regimen de pago":       "regimenPago",
+	"regimen de pagos":      "regimenPago",
+	"regimen":               "regimenPago",
+	"estado":                "estado",
+	"actividad economica":   "actividad",
+}
+
+// detectColumns interpreta la primera fila como cabecera. Devuelve el mapeo
+// posicional si no la reconoce, y hasHeader=false si la fila parece un dato.
+func detectColumns(header []string) (cm columnMap, hasHeader bool) {
+	cm = columnMap{-1, -1, -1, -1, -1, -1, -1}
+	fields := map[string]*int{
+		"rnc":             &cm.rnc,
+		"razonSocial":     &cm.razonSocial,
+		"nombreComercial": &cm.nombreComercial,
+		"categoria":       &cm.categoria,
+		"regimenPago":     &cm.regimenPago,
+		"estado":          &cm.estado,
+		"actividad":       &cm.actividad,
+	}
+	for i, h := range header {
+		if name, ok := headerAliases[normalizeHeader(h)]; ok && *fields[name] < 0 {
+			*fields[name] = i
+		}
+	}
+	if cm.rnc >= 0 && cm.razonSocial >= 0 {
+		return cm, true
+	}
+
+	if len(header) > 0 && isDigits(strings.TrimSpace(header[0])) {
+		slog.Warn("CSV has no header row, using positional columns")
+		return positionalColumns, false
+	}
+	slog.Warn("unrecognized CSV header, using positional columns", "header", header)
+	return positionalColumns, true
+}
+
+// columnNames normaliza la cabecera para usarla como claves de Index.Raw
+// ("Razón Social" -> "razon_social").
+func columnNames(header []string) []string {
+	out := make([]string, len(header))
+	for i, h := range header {
+		out[i] = strings.ReplaceAll(normalizeHeader(h), " ", "_")
+	}
+	return out
+}
+
+// columnName es el nombre de la columna i; las que no tienen cabecera se
+// llaman column_1, column_2...
+func columnName(columns []string, i int) string {
+	if i < len(columns) && columns[i] != "" {
+		return columns[i]
+	}
+	return "column_" + strconv.Itoa(i+1)
+}
+
+func (c columnMap) minLen() int {
+	if c == positionalColumns {
+		return 5 // filas históricas con al menos 5 columnas
+	}
+	return max(c.rnc, c.razonSocial) + 1
+}
+
+func (c columnMap) get(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// normalizeHeader pasa a minúsculas, quita acentos y reduce separadores
+// ("Nombre/Razón Social" -> "nombre razon social").
+func normalizeHeader(h string) string {
+	h = Fold(strings.TrimPrefix(h, "\ufeff"))
+	return strings.Join(strings.FieldsFunc(h, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+/* ---------- CSV helper ---------- */
+
+// newCSVReader lee r con el separador delim (la coma si es 0).
+func newCSVReader(r io.Reader, delim rune) *csv.Reader {
+	cr := csv.NewReader(r)
+	if delim != 0 {
+		cr.Comma = delim
+	}
+	cr.LazyQuotes = true
+	cr.FieldsPerRecord = -1 // las filas cortas se descartan en scanCSV
+	// sin ReuseRecord: scanCSV pasa cada fila a otra goroutine
+	return cr
+}
+
+// sniffSize es cuánto del inicio del archivo se examina para elegir la
+// codificación.
+const sniffSize = 64 * 1024
+
+// decodeReader devuelve un lector UTF-8 para r y si sus campos hay que
+// pasarlos por repairRow. El primer bloque decide: si es sobre todo UTF-8 se
+// lee tal cual y los bytes sueltos de otra codificación se reparan campo a
+// campo; si no, se asume Windows-1252, la codificación habitual de la DGII.
+func decodeReader(r io.Reader) (out io.Reader, repair bool) {
+	br := bufio.NewReaderSize(r, sniffSize)
+	head, _ := br.Peek(sniffSize)
+	if mostlyUTF8(head) {
+		return br, true
+	}
+	return transform.NewReader(br, charmap.Windows1252.NewDecoder()), false
+}
+
+// mostlyUTF8 dice si b es UTF-8 sin bytes inválidos o con no más que
+// caracteres multibyte válidos: un archivo UTF-8 con alguna fila pegada de
+// otro origen. En Windows-1252 casi ningún par de bytes forma UTF-8 válido.
+func mostlyUTF8(b []byte) bool {
+	multi, invalid := 0, 0
+	for len(b) > 0 && utf8.FullRune(b) { // tolera una runa cortada al final
+		r, n := utf8.DecodeRune(b)
+		switch {
+		case r == utf8.RuneError && n == 1:
+			invalid++
+		case n > 1:
+			multi++
+		}
+		b = b[n:]
+	}
+	return invalid == 0 || multi >= invalid
+}
+
+// repairRow deja en UTF-8 válido los campos de row que no lo son, para que
+// ningún byte suelto llegue al índice ni a las respuestas.
+func repairRow(row []string) {
+	for i, f := range row {
+		if !utf8.ValidString(f) {
+			row[i] = repairUTF8(f)
+		}
+	}
+}
+
+// repairUTF8 lee como Windows-1252 cada byte de s que no forma parte de una
+// secuencia UTF-8 válida: "CA\xd1A" queda como "CAÑA" y no con un carácter
+// de reemplazo, y el resto del texto UTF-8 no se toca.
+func repairUTF8(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 8)
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			r = charmap.Windows1252.DecodeByte(s[i])
+		}
+		b.WriteRune(r)
+		i += n
+	}
+	return b.String()
+}
@@ -0,0 +1,287 @@
+package rnc
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultURL es la ubicación oficial del padrón de contribuyentes de la DGII.
+const DefaultURL = "https://dgii.gov.do/app/WebApps/Consultas/RNC/RNC_CONTRIBUYENTES.zip"
+
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// Downloader descarga el ZIP de la DGII y extrae el CSV que contiene.
+// El valor cero usa DefaultURL, un cliente HTTP con timeout de 60s y un solo
+// intento por URL.
+type Downloader struct {
+	URL    string
+	Client *http.Client
+
+	// Mirrors son URLs alternativas que se prueban en orden si URL falla.
+	Mirrors []string
+	// Attempts es el número de intentos por URL ante errores transitorios
+	// (errores de red y respuestas 5xx). Entre intentos se espera Backoff,
+	// que se duplica en cada reintento (1s si es cero).
+	Attempts int
+	Backoff  time.Duration
+
+	// SHA256, si no está vacío, es el hash hexadecimal esperado del ZIP. Un
+	// ZIP que no coincide se descarta antes de extraerlo.
+	SHA256 string
+}
+
+// Download descarga el padrón desde DefaultURL y lo extrae en destPath.
+func Download(ctx context.Context, destPath string) error {
+	return (&Downloader{}).Download(ctx, destPath)
+}
+
+// ErrNotModified indica que el archivo de la DGII no ha cambiado
+// desde la versión indicada a DownloadIfChanged (HEAD sin cambios o 304).
+var ErrNotModified = errors.New("source not modified")
+
+// Version identifica una descarga por los validadores HTTP de la respuesta.
+type Version struct {
+	ETag          string `json:"etag,omitempty"`
+	LastModified  string `json:"lastModified,omitempty"`
+	ContentLength int64  `json:"contentLength,omitempty"`
+	SHA256        string `json:"sha256,omitempty"` // del ZIP descargado
+}
+
+func (v Version) isZero() bool {
+	return v == Version{}
+}
+
+// LoadVersion lee una Version guardada con Save. Si el archivo no existe
+// devuelve una Version vacía, que hace que la próxima descarga sea completa.
+func LoadVersion(path string) (Version, error) {
+	var v Version
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return v, err
+	}
+	return v, json.Unmarshal(data, &v)
+}
+
+// Save guarda la Version en path (JSON).
+func (v Version) Save(path string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Download descarga el ZIP y extrae su primer .csv en destPath. Si destPath
+// ya existe se reemplaza de forma atómica solo cuando todo salió bien.
+func (d *Downloader) Download(ctx context.Context, destPath string) error {
+	_, err := d.DownloadIfChanged(ctx, destPath, Version{})
+	return err
+}
+
+// DownloadIfChanged es como Download pero primero hace un HEAD y compara
+// Last-Modified y Content-Length con since; si coinciden, o si el GET
+// condicional (If-None-Match / If-Modified-Since) recibe un 304, devuelve
+// ErrNotModified sin tocar destPath. Devuelve la Version descargada, que el
+// llamador debe guardar solo cuando haya aceptado el archivo.
+func (d *Downloader) DownloadIfChanged(ctx context.Context, destPath string, since Version) (Version, error) {
+	urls := append([]string{cmp.Or(d.URL, DefaultURL)}, d.Mirrors...)
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return Version{}, fmt.Errorf("error creating CSV folder: %w", err)
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(destPath), "tmp_rncs")
+	if err != nil {
+		return Version{}, fmt.Errorf("error creating temporary folder: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tmpZipPath := filepath.Join(tmpDir, "RNC_CONTRIBUYENTES.zip")
+
+	for i, url := range urls {
+		var version Version
+		version, err = d.fetchWithRetry(ctx, client, url, since, tmpZipPath)
+		if err == nil {
+			slog.Info("ZIP downloaded", "url", url, "sha256", version.SHA256)
+			if err = d.checkSHA256(version.SHA256); err != nil {
+				os.Remove(tmpZipPath)
+			}
+		}
+		if err == nil {
+			if err := ExtractCSV(tmpZipPath, destPath); err != nil {
+				return Version{}, err
+			}
+			slog.Info("CSV file downloaded and extracted", "path", destPath, "url", url)
+			return version, nil
+		}
+		if errors.Is(err, ErrNotModified) || ctx.Err() != nil {
+			return since, err
+		}
+		if i < len(urls)-1 {
+			slog.Warn("Download failed, trying next mirror", "url", url, "err", err)
+		}
+	}
+	return Version{}, err
+}
+
+// checkSHA256 compara sum con d.SHA256, si se configuró.
+func (d *Downloader) checkSHA256(sum string) error {
+	if d.SHA256 == "" || strings.EqualFold(sum, d.SHA256) {
+		return nil
+	}
+	return fmt.Errorf("ZIP checksum mismatch: got sha256 %s, expected %s", sum, d.SHA256)
+}
+
+// fetchWithRetry llama a fetch hasta d.Attempts veces mientras el error sea
+// transitorio, con espera exponencial cancelable por ctx.
+func (d *Downloader) fetchWithRetry(ctx context.Context, client *http.Client, url string, since Version, zipPath string) (Version, error) {
+	attempts := max(d.Attempts, 1)
+	wait := cmp.Or(d.Backoff, time.Second)
+	for n := 1; ; n++ {
+		v, err := fetch(ctx, client, url, since, zipPath)
+		var te transientError
+		if err == nil || !errors.As(err, &te) || n == attempts {
+			return v, err
+		}
+		slog.Warn("Download failed, retrying", "url", url, "attempt", n, "wait", wait, "err", err)
+		select {
+		case <-ctx.Done():
+			return Version{}, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// transientError marca los fallos que vale la pena reintentar.
+type transientError struct{ err error }
+
+func (e transientError) Error() string { return e.err.Error() }
+func (e transientError) Unwrap() error { return e.err }
+
+// fetch descarga url en zipPath respetando since.
+func fetch(ctx context.Context, client *http.Client, url string, since Version, zipPath string) (Version, error) {
+	if !since.isZero() && unchanged(ctx, client, url, since) {
+		return since, ErrNotModified
+	}
+
+	// Download ZIP with User-Agent
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Version{}, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if since.ETag != "" {
+		req.Header.Set("If-None-Match", since.ETag)
+	}
+	if since.LastModified != "" {
+		req.Header.Set("If-Modified-Since", since.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return Version{}, ctx.Err()
+		}
+		return Version{}, transientError{fmt.Errorf("error downloading ZIP: %w", err)}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return since, ErrNotModified
+	}
+	if resp.StatusCode >= 500 {
+		return Version{}, transientError{fmt.Errorf("HTTP error downloading ZIP: %s", resp.Status)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Version{}, fmt.Errorf("HTTP error downloading ZIP: %s", resp.Status)
+	}
+	version := Version{
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		ContentLength: max(resp.ContentLength, 0),
+	}
+
+	outZip, err := os.Create(zipPath)
+	if err != nil {
+		return Version{}, fmt.Errorf("error creating temporary ZIP file: %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(outZip, h), resp.Body); err != nil {
+		outZip.Close()
+		if ctx.Err() != nil {
+			return Version{}, ctx.Err()
+		}
+		return Version{}, transientError{fmt.Errorf("error saving ZIP: %w", err)}
+	}
+	if err := outZip.Close(); err != nil {
+		return Version{}, fmt.Errorf("error saving ZIP: %w", err)
+	}
+	version.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return version, nil
+}
+
+// unchanged hace un HEAD y compara sus validadores con since. Ante cualquier
+// error o dato ausente responde false para que se intente la descarga.
+func unchanged(ctx context.Context, client *http.Client, url string, since Version) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || since.LastModified == "" {
+		return false
+	}
+	if resp.Header.Get("Last-Modified") != since.LastModified {
+		return false
+	}
+	return since.ContentLength == 0 || resp.ContentLength == since.ContentLength
+}
+
+// ExtractCSV copia el primer miembro .csv del ZIP en zipPath a destPath, de
+// forma atómica. Sirve para ZIPs obtenidos por otros medios.
+func ExtractCSV(zipPath, destPath string) error {
+	rc, err := openZipCSV(zipPath)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Se extrae a un temporal junto a destPath y se renombra al final,
+	// así una extracción interrumpida nunca deja un CSV a medias.
+	out, err := os.CreateTemp(filepath.Dir(destPath), filepath.Base(destPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error creating CSV file: %w", err)
+	}
+	defer os.Remove(out.Name())
+	buf := make([]byte, 32*1024)
+	if _, err := io.CopyBuffer(out, rc, buf); err != nil {
+		out.Close()
+		return fmt.Errorf("error extracting CSV: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("error extracting CSV: %w", err)
+	}
+	return os.Rename(out.Name(), destPath)
+}
@@ -0,0 +1,186 @@
+package rnc
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testZIP devuelve un ZIP con un único miembro name de contenido data.
+func testZIP(t *testing.T, name, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestDownloadIfChanged comprueba que una segunda descarga envía el ETag de
+// la primera y que un 304 deja intacto el CSV en disco.
+func TestDownloadIfChanged(t *testing.T) {
+	const etag = `"v1"`
+	body := testZIP(t, "RNC_CONTRIBUYENTES.csv", testHeader+"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	var full atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if r.Method == http.MethodGet {
+			full.Add(1)
+			w.Write(body)
+		}
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "rncs.csv")
+	d := &Downloader{URL: srv.URL}
+	v, err := d.DownloadIfChanged(context.Background(), dest, Version{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.ETag != etag {
+		t.Errorf("ETag = %q, want %q", v.ETag, etag)
+	}
+	if err := os.WriteFile(dest, []byte("local copy"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.DownloadIfChanged(context.Background(), dest, v); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("second download: err = %v, want ErrNotModified", err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != "local copy" {
+		t.Errorf("304 replaced the CSV: %q", got)
+	}
+	if n := full.Load(); n != 1 {
+		t.Errorf("server sent the ZIP %d times, want 1", n)
+	}
+}
+
+// TestDownloadHEAD comprueba que si el HEAD trae el mismo Last-Modified y
+// tamaño que la última descarga no se pide el ZIP.
+func TestDownloadHEAD(t *testing.T) {
+	const lastMod = "Mon, 02 Jan 2006 15:04:05 GMT"
+	body := testZIP(t, "RNC_CONTRIBUYENTES.csv", testHeader+"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	var gets atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		w.Header().Set("Last-Modified", lastMod)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "rncs.csv")
+	d := &Downloader{URL: srv.URL}
+	v, err := d.DownloadIfChanged(context.Background(), dest, Version{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.DownloadIfChanged(context.Background(), dest, v); !errors.Is(err, ErrNotModified) {
+		t.Fatalf("unchanged HEAD: err = %v, want ErrNotModified", err)
+	}
+	if n := gets.Load(); n != 1 {
+		t.Errorf("%d GETs, want 1", n)
+	}
+
+	v.ContentLength++ // otro tamaño: se descarga
+	if _, err := d.DownloadIfChanged(context.Background(), dest, v); err != nil {
+		t.Fatalf("changed size: %v", err)
+	}
+	if n := gets.Load(); n != 2 {
+		t.Errorf("%d GETs after a size change, want 2", n)
+	}
+}
+
+// TestDownloadRetryMirrors comprueba que un 5xx se reintenta, que un 404 no,
+// y que tras agotar los intentos se pasa al siguiente mirror.
+func TestDownloadRetryMirrors(t *testing.T) {
+	body := testZIP(t, "RNC_CONTRIBUYENTES.csv", testHeader+"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	var down, gone, mirror atomic.Int32
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		down.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gone.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer notFound.Close()
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirror.Add(1)
+		w.Write(body)
+	}))
+	defer ok.Close()
+
+	dest := filepath.Join(t.TempDir(), "rncs.csv")
+	d := &Downloader{
+		URL:      unavailable.URL,
+		Mirrors:  []string{notFound.URL, ok.URL},
+		Attempts: 3,
+		Backoff:  time.Millisecond,
+	}
+	if err := d.Download(context.Background(), dest); err != nil {
+		t.Fatal(err)
+	}
+	if got := [3]int32{down.Load(), gone.Load(), mirror.Load()}; got != [3]int32{3, 1, 1} {
+		t.Errorf("requests per server = %v, want [3 1 1]", got)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("CSV not extracted: %v", err)
+	}
+}
+
+// TestDownloadSHA256 comprueba que se informa el SHA-256 del ZIP y que un
+// ZIP que no coincide con el esperado no se extrae.
+func TestDownloadSHA256(t *testing.T) {
+	body := testZIP(t, "RNC_CONTRIBUYENTES.csv", testHeader+"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	sum := sha256.Sum256(body)
+	want := hex.EncodeToString(sum[:])
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "rncs.csv")
+	d := &Downloader{URL: srv.URL, SHA256: strings.ToUpper(want)}
+	v, err := d.DownloadIfChanged(context.Background(), dest, Version{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.SHA256 != want {
+		t.Errorf("Version.SHA256 = %q, want %q", v.SHA256, want)
+	}
+
+	dest = filepath.Join(t.TempDir(), "rncs.csv")
+	d.SHA256 = strings.Repeat("0", 64)
+	if _, err := d.DownloadIfChanged(context.Background(), dest, Version{}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Fatalf("wrong checksum: err = %v, want a mismatch", err)
+	}
+	if _, err := os.Stat(dest); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("CSV extracted despite the checksum mismatch: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package rnc
+
+import "strings"
+
+/* ---------- Almacenamiento compacto de entradas ---------- */
+
+// entry es lo que tables guarda de cada empresa: Empresa sin el RNC, que es
+// la clave de byRNC, y con los campos de pocos valores distintos (estado,
+// actividad, régimen, categoría) reemplazados por su número en tables.dict.
+// Ocupa menos de la mitad que una Empresa.
+type entry struct {
+	social, comercial string
+	status, activity  uint32
+	regime, category  uint32
+	extra             map[string]string
+}
+
+// dict asigna un número a cada texto distinto. Un padrón de un millón de
+// empresas tiene unas pocas decenas de estados y categorías y algo más de
+// mil actividades económicas.
+type dict struct {
+	ids  map[string]uint32
+	vals []string
+}
+
+func (d *dict) id(s string) uint32 {
+	if id, ok := d.ids[s]; ok {
+		return id
+	}
+	if d.ids == nil {
+		d.ids = make(map[string]uint32)
+	}
+	id := uint32(len(d.vals))
+	d.ids[s] = id
+	d.vals = append(d.vals, strings.Clone(s))
+	return id
+}
+
+// arenaChunk es el tamaño de cada bloque de arena.
+const arenaChunk = 1 << 20
+
+// arena copia textos en bloques grandes y compartidos. Los campos que
+// devuelve el lector de CSV son subcadenas de la línea entera; guardarlos tal
+// cual retendría en memoria también las columnas que no se usan.
+type arena struct {
+	b strings.Builder
+}
+
+// copy devuelve una copia de s dentro del bloque actual.
+func (a *arena) copy(s string) string {
+	if s == "" {
+		return ""
+	}
+	if a.b.Cap()-a.b.Len() < len(s) {
+		// un bloque nuevo: los textos ya devueltos siguen apuntando al
+		// anterior, que no se vuelve a tocar
+		a.b = strings.Builder{}
+		a.b.Grow(max(arenaChunk, len(s)))
+	}
+	a.b.WriteString(s)
+	all := a.b.String()
+	return all[len(all)-len(s):]
+}
+
+// put guarda emp en byRNC (el llamador ya contó si era un duplicado) y
+// devuelve la clave, copiada en la arena para usarla también en byName.
+func (t *tables) put(emp Empresa) string {
+	k := t.arena.copy(emp.RNC)
+	e := entry{
+		social:   t.arena.copy(emp.SocialName),
+		status:   t.dict.id(emp.Status),
+		activity: t.dict.id(emp.EconomicActivity),
+		regime:   t.dict.id(emp.PaymentRegime),
+		category: t.dict.id(emp.Category),
+		extra:    emp.Extra,
+	}
+	e.comercial = e.social // sin nombre comercial, mapToAPI repite el social
+	if emp.ComercialName != emp.SocialName {
+		e.comercial = t.arena.copy(emp.ComercialName)
+	}
+	for name, v := range e.extra {
+		e.extra[name] = t.arena.copy(v)
+	}
+	t.byRNC[k] = e
+	return k
+}
+
+// copyRow copia en la arena los campos de row, una fila completa para
+// Options.Full, y la devuelve. Sin la copia cada fila retendría su línea del
+// CSV o, al cargarla del caché, una reserva por campo.
+func (t *tables) copyRow(row []string) []string {
+	for i, f := range row {
+		row[i] = t.arena.copy(f)
+	}
+	return row
+}
+
+// get devuelve la empresa de clave k.
+func (t *tables) get(k string) (Empresa, bool) {
+	e, ok := t.byRNC[k]
+	if !ok {
+		return Empresa{}, false
+	}
+	return Empresa{
+		RNC:              k,
+		SocialName:       e.social,
+		ComercialName:    e.comercial,
+		Status:           t.dict.vals[e.status],
+		EconomicActivity: t.dict.vals[e.activity],
+		PaymentRegime:    t.dict.vals[e.regime],
+		Category:         t.dict.vals[e.category],
+		Extra:            e.extra,
+	}, true
+}
+
+// empresa es get para una clave que se sabe presente.
+func (t *tables) empresa(k string) Empresa {
+	emp, _ := t.get(k)
+	return emp
+}
@@ -0,0 +1,110 @@
+package rnc_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yolfry/rncs/rnc"
+)
+
+// fixture es un padrón pequeño con la cabecera de la DGII.
+const fixture = "testdata/rncs.csv"
+
+func ExampleNewIndexFromCSV() {
+	idx, err := rnc.NewIndexFromCSV(fixture)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if emp, ok := idx.Lookup("1-32-13827-9"); ok {
+		fmt.Println(emp.RNC, emp.SocialName, emp.Status)
+	}
+	// Output: 132138279 FERRETERIA AMERICANA SRL ACTIVO
+}
+
+func ExampleIndex_Search() {
+	idx, err := rnc.NewIndexFromCSV(fixture)
+	if err != nil {
+		log.Fatal(err)
+	}
+	res, total := idx.Search("ferreteria", 10, 0)
+	fmt.Println(total)
+	for _, emp := range res {
+		fmt.Println(emp.RNC, emp.SocialName)
+	}
+	// Output:
+	// 2
+	// 132138279 FERRETERIA AMERICANA SRL
+	// 101000122 FERRETERIA OMEGA SRL
+}
+
+// TestLibrary usa el paquete como lo haría otro programa: abre el padrón de
+// testdata, consulta por RNC y por nombre, y lo recarga tras cambiar el
+// archivo.
+func TestLibrary(t *testing.T) {
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "rncs.csv")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := rnc.NewIndexFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 5 {
+		t.Errorf("Len = %d, want 5", idx.Len())
+	}
+
+	lookups := []struct {
+		in, want string
+	}{
+		{"132138279", "FERRETERIA AMERICANA SRL"},
+		{"1-31-00001-2", "JOSÉ PEÑA SRL"},
+		{"001-1391820-5", "JUAN PEREZ"},
+		{"401506254", ""},
+	}
+	for _, tt := range lookups {
+		emp, ok := idx.Lookup(tt.in)
+		if ok != (tt.want != "") || emp.SocialName != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q", tt.in, emp.SocialName, ok, tt.want)
+		}
+	}
+
+	searches := []struct {
+		q     string
+		total int
+	}{
+		{"ferreteria", 2},
+		{"pena", 1}, // sin acentos encuentra PEÑA
+		{"FERRETODO", 1},
+		{"no existe", 0},
+	}
+	for _, tt := range searches {
+		if _, total := idx.Search(tt.q, 10, 0); total != tt.total {
+			t.Errorf("Search(%q) total = %d, want %d", tt.q, total, tt.total)
+		}
+	}
+
+	row := "401506254,DISTRIBUIDORA DEL ESTE SRL,,COMERCIO,01/01/2020,ACTIVO,NORMAL\n"
+	if err := os.WriteFile(path, append(data, row...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if emp, ok := idx.Lookup("401506254"); !ok || idx.Len() != 6 {
+		t.Errorf("after Reload: Lookup = %+v, %v, Len = %d; want the new row and 6 entries", emp, ok, idx.Len())
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Reload(); err == nil || idx.Len() != 6 {
+		t.Errorf("Reload of a missing file: err = %v, Len = %d; want an error and the old data", err, idx.Len())
+	}
+}
@@ -0,0 +1,96 @@
+package rnc
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"iter"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/* ---------- Exportación ---------- */
+
+// exportFlushEvery es cada cuántas filas se envía lo acumulado al cliente.
+const exportFlushEvery = 1000
+
+// exportColumns es la cabecera de la exportación en CSV.
+var exportColumns = []string{"rnc", "socialName", "comercialName", "status", "economicActivity", "paymentRegime", "category"}
+
+// export atiende GET /api/export?format=ndjson|csv&status=ACTIVO. Va
+// escribiendo el padrón (o solo un estado) a medida que lo recorre, sin
+// armar la respuesta en memoria, y se detiene si el cliente se desconecta.
+func (h *handler) export(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = "ndjson"
+	}
+	if format != "ndjson" && format != "csv" {
+		WriteError(w, http.StatusBadRequest, "format must be ndjson or csv")
+		return
+	}
+	idx, ok := h.currentIndex(w, r)
+	if !ok {
+		return
+	}
+	var rows iter.Seq[Empresa]
+	if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
+		rows = idx.WithStatus(status)
+	} else {
+		rows = idx.All()
+	}
+
+	hdr := w.Header()
+	if format == "csv" {
+		hdr.Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		hdr.Set("Content-Type", "application/x-ndjson")
+	}
+	hdr.Set("Content-Disposition", `attachment; filename="rncs.`+format+`"`)
+	hdr.Set("X-Data-Version", idx.DataVersion())
+	// recorrer todo el padrón tarda más que el WriteTimeout habitual
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(h.exportTimeout)); err != nil {
+		slog.Warn("Could not extend write deadline", "err", err)
+	}
+
+	rc := http.NewResponseController(w)
+	bw := bufio.NewWriterSize(w, 32*1024)
+	var write func(Empresa) error
+	if format == "csv" {
+		cw := csv.NewWriter(bw)
+		cw.Write(exportColumns)
+		write = func(e Empresa) error {
+			cw.Write([]string{e.RNC, e.SocialName, e.ComercialName, e.Status, e.EconomicActivity, e.PaymentRegime, e.Category})
+			cw.Flush()
+			return cw.Error()
+		}
+	} else {
+		enc := json.NewEncoder(bw)
+		write = func(e Empresa) error { return enc.Encode(e) }
+	}
+
+	n := 0
+	for e := range rows {
+		if err := write(e); err != nil {
+			slog.Warn("Export aborted", "rows", n, "err", err)
+			return
+		}
+		if n++; n%exportFlushEvery == 0 {
+			if r.Context().Err() != nil {
+				slog.Info("Export cancelled by client", "rows", n)
+				return
+			}
+			if err := bw.Flush(); err != nil {
+				slog.Warn("Export aborted", "rows", n, "err", err)
+				return
+			}
+			rc.Flush()
+		}
+	}
+	bw.Flush()
+}
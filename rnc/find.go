@@ -0,0 +1,73 @@
+package rnc
+
+import "errors"
+
+/* ---------- Consultas con error ---------- */
+
+// Errores de Find y FindRaw.
+var (
+	ErrMalformedRNC = errors.New("RNC must contain only digits")
+	ErrInvalidRNC   = errors.New("invalid RNC format")
+	ErrNotFound     = errors.New("This RNC does not exist")
+)
+
+// Find busca id en s y distingue los RNC mal formados (ErrMalformedRNC), los
+// que no cumplen el dígito verificador (ErrInvalidRNC) y los que no existen
+// (ErrNotFound).
+func Find(s Store, id string) (Empresa, error) {
+	norm, ok := Normalize(id)
+	if !ok {
+		return Empresa{}, ErrMalformedRNC
+	}
+	if emp, ok := s.Lookup(norm); ok {
+		return emp, nil
+	}
+	// Se valida después de buscar para no ocultar registros reales de la DGII
+	// que no cumplan el dígito verificador.
+	if !Valid(norm) {
+		return Empresa{}, ErrInvalidRNC
+	}
+	return Empresa{}, ErrNotFound
+}
+
+// FindRaw es Find con todas las columnas del registro. Requiere un Index
+// construido con Options.Full; cualquier otro Store da ErrNoRawData.
+func FindRaw(s Store, id string) (map[string]string, error) {
+	norm, ok := Normalize(id)
+	if !ok {
+		return nil, ErrMalformedRNC
+	}
+	idx, ok := s.(*Index)
+	if !ok {
+		return nil, ErrNoRawData
+	}
+	raw, ok, err := idx.Raw(norm)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return raw, nil
+	}
+	if !Valid(norm) {
+		return nil, ErrInvalidRNC
+	}
+	return nil, ErrNotFound
+}
+
+// FindMany devuelve los registros de ids que existen en s y, en el orden
+// recibido, los que no. Un Index resuelve todo el lote sobre una misma
+// versión de los datos.
+func FindMany(s Store, ids []string) (found []Empresa, missing []string) {
+	if idx, ok := s.(*Index); ok {
+		return idx.LookupMany(ids)
+	}
+	found, missing = make([]Empresa, 0, len(ids)), make([]string, 0)
+	for _, id := range ids {
+		if emp, ok := s.Lookup(id); ok {
+			found = append(found, emp)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing
+}
@@ -0,0 +1,622 @@
+package rnc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* ---------- API HTTP ---------- */
+
+// ErrNotReady es el error de un Store que todavía se está cargando (ver
+// WithStoreFunc); las rutas de NewHandler lo responden con 503 y
+// Retry-After.
+var ErrNotReady = errors.New("index loading")
+
+// NotReadyRetryAfter es el Retry-After de las respuestas a ErrNotReady.
+const NotReadyRetryAfter = 5 * time.Second
+
+const (
+	defaultSearchLimit     = 20
+	defaultSearchNameLimit = 50
+	maxSearchLimit         = 100
+	maxSearchNameLimit     = 200
+	defaultSuggestLimit    = 10
+	defaultStatusLimit     = 100
+	maxStatusLimit         = 1000
+)
+
+const (
+	maxBatchSize = 1000
+	maxBatchBody = 1 << 20
+)
+
+// HandlerOption ajusta NewHandler.
+type HandlerOption func(*handler)
+
+// WithStoreFunc hace que cada petición tome los datos de get en lugar del
+// Store fijo de NewHandler, para cargarlos bajo demanda o cambiarlos en
+// caliente. get recibe el contexto de la petición.
+func WithStoreFunc(get func(ctx context.Context) (Store, error)) HandlerOption {
+	return func(h *handler) { h.store = get }
+}
+
+// WithoutRoutes desactiva rutas por su patrón ("/api/reload",
+// "/api/export"...); responden 404 como cualquier ruta que no existe.
+func WithoutRoutes(patterns ...string) HandlerOption {
+	return func(h *handler) {
+		for _, p := range patterns {
+			h.disabled[p] = true
+		}
+	}
+}
+
+// WithRouteWrapper envuelve el handler de cada ruta; wrap recibe el patrón
+// (no la URL), por ejemplo para contar peticiones por endpoint.
+func WithRouteWrapper(wrap func(pattern string, h http.Handler) http.Handler) HandlerOption {
+	return func(h *handler) { h.wrap = wrap }
+}
+
+// WithLookupHook llama a hook tras cada búsqueda con el patrón de la ruta y
+// si encontró algo.
+func WithLookupHook(hook func(pattern string, hit bool)) HandlerOption {
+	return func(h *handler) { h.lookupHook = hook }
+}
+
+// WithVerifyThreshold fija la similitud mínima de nombre, en (0, 1], para
+// que /api/verify responda match. Por defecto 0.85.
+func WithVerifyThreshold(t float64) HandlerOption {
+	return func(h *handler) { h.verifyThreshold = t }
+}
+
+// WithCacheMaxAge fija el max-age del Cache-Control de /api/checkrnc. Por
+// defecto 0: los clientes revalidan con If-None-Match.
+func WithCacheMaxAge(d time.Duration) HandlerOption {
+	return func(h *handler) { h.cacheMaxAge = d }
+}
+
+// WithExportTimeout fija el plazo de escritura de /api/export, que recorre
+// todo el padrón. Por defecto 10 minutos.
+func WithExportTimeout(d time.Duration) HandlerOption {
+	return func(h *handler) { h.exportTimeout = d }
+}
+
+type handler struct {
+	mux   *http.ServeMux
+	store func(ctx context.Context) (Store, error)
+
+	disabled        map[string]bool
+	wrap            func(pattern string, h http.Handler) http.Handler
+	lookupHook      func(pattern string, hit bool)
+	verifyThreshold float64
+	cacheMaxAge     time.Duration
+	exportTimeout   time.Duration
+}
+
+// NewHandler devuelve la API de consulta sobre s: /api/checkrnc/{RNC},
+// /api/checkrnc/batch, /api/validate/{NUMBER}, /api/search,
+// /api/searchname/{QUERY}, /api/rncs, /api/statuses, /api/export,
+// /api/verify, /api/suggest, /api/suggest/{PREFIX}, /api/status y
+// POST /api/reload. No abre puertos ni añade CORS, autenticación o logs:
+// se monta en el mux de quien lo llama, p. ej. bajo un prefijo con
+// http.StripPrefix. Las rutas de búsqueda por nombre, estado y exportación
+// requieren un *Index y con otro Store responden 501.
+//
+// s puede ser nil si se usa WithStoreFunc. POST /api/reload no pide
+// credenciales: desactívela con WithoutRoutes o protéjala por fuera.
+func NewHandler(s Store, opts ...HandlerOption) http.Handler {
+	h := &handler{
+		mux: http.NewServeMux(),
+		store: func(context.Context) (Store, error) {
+			if s == nil {
+				return nil, ErrNotReady
+			}
+			return s, nil
+		},
+		disabled:        make(map[string]bool),
+		verifyThreshold: 0.85,
+		exportTimeout:   10 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.route("/api/checkrnc/", h.checkRNC)
+	h.route("/api/validate/", h.validate)
+	h.route("/api/checkrnc/batch", h.checkBatch)
+	h.route("/api/search", h.search)
+	h.route("/api/searchname/", h.searchName)
+	h.route("/api/rncs", h.byStatus)
+	h.route("/api/statuses", h.statuses)
+	h.route("/api/export", h.export)
+	h.route("/api/verify", h.verify)
+	h.route("/api/suggest", h.suggestName)
+	h.route("/api/suggest/", h.suggestRNC)
+	h.route("/api/status", h.status)
+	h.route("/api/reload", h.reload)
+
+	// Cualquier otra ruta: 404 en JSON en vez del texto por defecto
+	h.mux.HandleFunc("/", notFound)
+	return h.mux
+}
+
+// route registra pattern salvo que esté desactivado.
+func (h *handler) route(pattern string, fn http.HandlerFunc) {
+	if h.disabled[pattern] {
+		return
+	}
+	var hd http.Handler = fn
+	if h.wrap != nil {
+		hd = h.wrap(pattern, hd)
+	}
+	h.mux.Handle(pattern, hd)
+}
+
+// countLookup avisa a WithLookupHook, si lo hay.
+func (h *handler) countLookup(pattern string, hit bool) {
+	if h.lookupHook != nil {
+		h.lookupHook(pattern, hit)
+	}
+}
+
+// current devuelve los datos para la petición o responde el error.
+func (h *handler) current(w http.ResponseWriter, r *http.Request) (Store, bool) {
+	s, err := h.store(r.Context())
+	if err != nil {
+		WriteStoreError(w, err)
+		return nil, false
+	}
+	return s, true
+}
+
+// currentIndex es current para las rutas que requieren un *Index.
+func (h *handler) currentIndex(w http.ResponseWriter, r *http.Request) (*Index, bool) {
+	s, ok := h.current(w, r)
+	if !ok {
+		return nil, false
+	}
+	idx, ok := s.(*Index)
+	if !ok {
+		WriteError(w, http.StatusNotImplemented, "not available with an on-disk store")
+		return nil, false
+	}
+	return idx, true
+}
+
+// WriteStoreError responde a un error al obtener los datos: 503 con
+// Retry-After si aún se están cargando y 500 en otro caso.
+func WriteStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotReady) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(NotReadyRetryAfter/time.Second)))
+		WriteError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	slog.Error("Error loading index", "err", err)
+	WriteError(w, http.StatusInternalServerError, "Error loading index")
+}
+
+// GET /api/checkrnc/{RNC}?full=1&suggest=1
+func (h *handler) checkRNC(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	id, err := PathParam(r, "/api/checkrnc/", "RNC")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s, ok := h.current(w, r)
+	if !ok {
+		return
+	}
+	// versión tomada antes de buscar: si una recarga se cuela, el cliente
+	// verá datos nuevos con la versión vieja y los pedirá de nuevo
+	dataVersion := s.DataVersion()
+	// ?full=1: todas las columnas de la DGII (requiere Options.Full)
+	var out any
+	if full := r.URL.Query().Get("full"); full == "1" || full == "true" {
+		out, err = FindRaw(s, id)
+	} else {
+		out, err = Find(s, id)
+	}
+	h.countLookup("/api/checkrnc/", err == nil)
+	switch {
+	case errors.Is(err, ErrNoRawData):
+		WriteError(w, http.StatusBadRequest, "full records are not available: build the index with full records")
+		return
+	case errors.Is(err, ErrMalformedRNC):
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, ErrInvalidRNC) || errors.Is(err, ErrNotFound):
+		resp := ErrorResponse{Error: err.Error()}
+		code := http.StatusNotFound
+		if errors.Is(err, ErrInvalidRNC) {
+			code = http.StatusUnprocessableEntity
+		}
+		// ?suggest=1: RNC parecidos que sí existen (errores de tipeo)
+		if sg := r.URL.Query().Get("suggest"); sg == "1" || sg == "true" {
+			if idx, ok := s.(*Index); ok {
+				resp.Suggestions = idx.Similar(id, MaxSimilar)
+			}
+		}
+		WriteJSON(w, code, resp)
+		return
+	case err != nil:
+		WriteStoreError(w, err)
+		return
+	}
+	if h.notModified(w, r, dataVersion) {
+		return
+	}
+	WriteJSON(w, http.StatusOK, out)
+}
+
+// GET /api/validate/{NUMBER}: solo dígito verificador, sin datos
+func (h *handler) validate(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	number, err := PathParam(r, "/api/validate/", "Number")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	WriteJSON(w, http.StatusOK, Validate(number))
+}
+
+type batchRequest struct {
+	RNCs []string `json:"rncs"`
+}
+
+type batchResult struct {
+	Results  []Empresa `json:"results"`
+	NotFound []string  `json:"notFound"`
+}
+
+// POST /api/checkrnc/batch {"rncs":["...", ...]}
+func (h *handler) checkBatch(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req batchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody)).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if len(req.RNCs) > maxBatchSize {
+		WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Too many RNCs (max %d)", maxBatchSize))
+		return
+	}
+	s, ok := h.current(w, r)
+	if !ok {
+		return
+	}
+	found, missing := FindMany(s, req.RNCs)
+	WriteJSON(w, http.StatusOK, batchResult{Results: found, NotFound: missing})
+}
+
+type searchResult struct {
+	Total   int       `json:"total"`
+	Results []Empresa `json:"results"`
+}
+
+// pagedResult es searchResult con la página solicitada.
+type pagedResult struct {
+	Total   int       `json:"total"`
+	Limit   int       `json:"limit"`
+	Offset  int       `json:"offset"`
+	Results []Empresa `json:"results"`
+}
+
+// GET /api/search?q=ferreteria&limit=20&offset=0
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		WriteError(w, http.StatusBadRequest, "Query not provided")
+		return
+	}
+	limit, offset, err := parsePage(r, defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx, ok := h.currentIndex(w, r)
+	if !ok {
+		return
+	}
+	results, total := idx.Search(q, limit, offset)
+	h.countLookup("/api/search", total > 0)
+	WriteJSON(w, http.StatusOK, searchResult{Total: total, Results: results})
+}
+
+// GET /api/searchname/{QUERY}?limit=50&offset=0
+func (h *handler) searchName(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	q, err := PathParam(r, "/api/searchname/", "Query")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, offset, err := parsePage(r, defaultSearchNameLimit, maxSearchNameLimit)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx, ok := h.currentIndex(w, r)
+	if !ok {
+		return
+	}
+	results, total := idx.Search(q, limit, offset)
+	h.countLookup("/api/searchname/", total > 0)
+	WriteJSON(w, http.StatusOK, pagedResult{Total: total, Limit: limit, Offset: offset, Results: results})
+}
+
+// GET /api/rncs?status=ACTIVO&limit=100&offset=0: listado por estado
+func (h *handler) byStatus(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	status := strings.TrimSpace(r.URL.Query().Get("status"))
+	if status == "" {
+		WriteError(w, http.StatusBadRequest, "status not provided (see /api/statuses)")
+		return
+	}
+	limit, offset, err := parsePage(r, defaultStatusLimit, maxStatusLimit)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx, ok := h.currentIndex(w, r)
+	if !ok {
+		return
+	}
+	results, total := idx.ByStatus(status, limit, offset)
+	WriteJSON(w, http.StatusOK, pagedResult{Total: total, Limit: limit, Offset: offset, Results: results})
+}
+
+// GET /api/statuses: estados distintos y cuántos contribuyentes tiene cada uno
+func (h *handler) statuses(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	idx, ok := h.currentIndex(w, r)
+	if !ok {
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"statuses": idx.Statuses()})
+}
+
+// suggestion es una entrada de /api/suggest/.
+type suggestion struct {
+	RNC        string `json:"rnc"`
+	SocialName string `json:"socialName"`
+}
+
+// GET /api/suggest?q=FERRE&limit=10: autocompletado por nombre
+func (h *handler) suggestName(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	limit, _, err := parsePage(r, defaultSuggestLimit, maxSearchLimit)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx, ok := h.currentIndex(w, r)
+	if !ok {
+		return
+	}
+	out := idx.SuggestNames(r.URL.Query().Get("q"), limit)
+	h.countLookup("/api/suggest", len(out) > 0)
+	WriteJSON(w, http.StatusOK, out)
+}
+
+// GET /api/suggest/{PREFIX}?limit=10: autocompletado por RNC
+func (h *handler) suggestRNC(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	raw, err := PathParam(r, "/api/suggest/", "Prefix")
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	prefix, ok := Normalize(raw)
+	if !ok {
+		WriteError(w, http.StatusBadRequest, "prefix must contain only digits")
+		return
+	}
+	limit, _, err := parsePage(r, defaultSuggestLimit, maxSearchLimit)
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	idx, ok := h.currentIndex(w, r)
+	if !ok {
+		return
+	}
+	emps := idx.Prefix(prefix, limit)
+	out := make([]suggestion, len(emps))
+	for i, e := range emps {
+		out[i] = suggestion{RNC: e.RNC, SocialName: e.SocialName}
+	}
+	h.countLookup("/api/suggest/", len(out) > 0)
+	WriteJSON(w, http.StatusOK, out)
+}
+
+// storeStatus es la respuesta de /api/status: la frescura de los datos.
+type storeStatus struct {
+	DataVersion    string     `json:"dataVersion,omitempty"`
+	Entries        int        `json:"entries"`
+	Duplicates     int        `json:"duplicates"` // filas del CSV con un RNC repetido
+	LoadedAt       time.Time  `json:"loadedAt"`
+	CSVFileModTime *time.Time `json:"csvFileModTime,omitempty"`
+	CSVFileSize    int64      `json:"csvFileSize"`
+}
+
+// GET /api/status
+func (h *handler) status(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+	s, ok := h.current(w, r)
+	if !ok {
+		return
+	}
+	WriteJSON(w, http.StatusOK, statusOf(s))
+}
+
+func statusOf(s Store) storeStatus {
+	st := storeStatus{
+		DataVersion: s.DataVersion(), Entries: s.Len(), Duplicates: s.Duplicates(),
+		LoadedAt: s.LoadedAt(),
+	}
+	if mod, size := s.SourceInfo(); !mod.IsZero() {
+		st.CSVFileModTime, st.CSVFileSize = &mod, size
+	}
+	return st
+}
+
+// POST /api/reload: vuelve a leer el CSV de origen del Store
+func (h *handler) reload(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodPost) {
+		return
+	}
+	s, ok := h.current(w, r)
+	if !ok {
+		return
+	}
+	var err error
+	if rc, ok := s.(interface{ ReloadContext(context.Context) error }); ok {
+		err = rc.ReloadContext(r.Context())
+	} else {
+		err = s.Reload()
+	}
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "Error reloading CSV: "+err.Error())
+		return
+	}
+	WriteJSON(w, http.StatusOK, statusOf(s))
+}
+
+/* ---------- Respuestas ---------- */
+
+// MaxSimilar es el máximo de RNC parecidos que /api/checkrnc/{RNC}?suggest=1
+// sugiere tras un fallo.
+const MaxSimilar = 5
+
+// parsePage lee ?limit= y ?offset= validando sus rangos.
+func parsePage(r *http.Request, defLimit, maxLimit int) (limit, offset int, err error) {
+	limit = defLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
+
+// notModified añade ETag, X-Data-Version y Cache-Control para la versión
+// del padrón v y responde 304 si el cliente ya la tiene (If-None-Match).
+func (h *handler) notModified(w http.ResponseWriter, r *http.Request, v string) bool {
+	if v == "" {
+		return false
+	}
+	etag := `"` + v + `"`
+	hd := w.Header()
+	hd.Set("ETag", etag)
+	hd.Set("X-Data-Version", v)
+	hd.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.cacheMaxAge.Seconds())))
+	for _, t := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == etag || t == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// notFound atiende las rutas que no existen con un 404 en JSON.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	WriteJSON(w, http.StatusNotFound, ErrorResponse{Error: "not found", Path: r.URL.Path})
+}
+
+/* ---------- Rutas propias ---------- */
+
+// Las rutas de NewHandler responden con las funciones que siguen; quien
+// monte rutas propias junto a ellas puede usarlas para que los errores, los
+// 405 y los parámetros de ruta se comporten igual.
+
+// ErrorResponse es el cuerpo JSON de las respuestas de error.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	Path  string `json:"path,omitempty"` // solo en 404 y 405
+
+	// RNC parecidos que sí existen (/api/checkrnc/{RNC}?suggest=1)
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// WriteJSON responde code con v codificado en JSON.
+func WriteJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("json encode error", "err", err)
+	}
+}
+
+// WriteError responde code con {"error": msg}.
+func WriteError(w http.ResponseWriter, code int, msg string) {
+	WriteJSON(w, code, ErrorResponse{Error: msg})
+}
+
+// AllowMethods responde 405 con la cabecera Allow si r.Method no está entre
+// methods. Devuelve true si la petición puede seguir.
+func AllowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	if slices.Contains(methods, r.Method) {
+		return true
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	WriteJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Path: r.URL.Path})
+	return false
+}
+
+// PathParam devuelve el segmento de la ruta que sigue a prefix, decodificado
+// (%2D → -) y sin espacios alrededor; se tolera una barra final. Es un error
+// que falte o que tenga más segmentos (/api/checkrnc/123/extra); what nombra
+// el valor en el mensaje.
+func PathParam(r *http.Request, prefix, what string) (string, error) {
+	raw := strings.TrimSuffix(strings.TrimPrefix(r.URL.EscapedPath(), prefix), "/")
+	if strings.Contains(raw, "/") {
+		return "", fmt.Errorf("malformed path %q: expected %s{%s}", r.URL.Path, prefix, what)
+	}
+	v, err := url.PathUnescape(raw)
+	if err != nil {
+		return "", fmt.Errorf("malformed path %q: %w", r.URL.Path, err)
+	}
+	if v = strings.TrimSpace(v); v == "" {
+		return "", fmt.Errorf("%s not provided", what)
+	}
+	return v, nil
+}
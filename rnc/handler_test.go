@@ -0,0 +1,329 @@
+package rnc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRows son dos empresas válidas para los tests de la API.
+const testRows = "" +
+	"132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n" +
+	"101010632,CONSTRUCTORA DEL CARIBE SA,CARIBE,CONSTRUCCION,01/01/2000,SUSPENDIDO,NORMAL\n"
+
+// do envía una petición a h y devuelve el código y el cuerpo.
+func do(t *testing.T, h http.Handler, method, path, body string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec.Code, rec.Body.String()
+}
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	return do(t, h, http.MethodGet, path, "")
+}
+
+func TestBatch(t *testing.T) {
+	h := NewHandler(newTestIndex(t, testRows))
+
+	code, body := do(t, h, http.MethodPost, "/api/checkrnc/batch", `{"rncs":["132138279","131000012","101010632","999"]}`)
+	if code != http.StatusOK {
+		t.Fatalf("batch = %d %s, want 200", code, body)
+	}
+	var got struct {
+		Results []struct {
+			RNC string `json:"rnc"`
+		} `json:"results"`
+		NotFound []string `json:"notFound"`
+	}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for _, r := range got.Results {
+		found = append(found, r.RNC)
+	}
+	if want := []string{"132138279", "101010632"}; !slices.Equal(found, want) {
+		t.Errorf("results = %v, want %v", found, want)
+	}
+	if want := []string{"131000012", "999"}; !slices.Equal(got.NotFound, want) {
+		t.Errorf("notFound = %v, want %v", got.NotFound, want)
+	}
+
+	ids := make([]string, maxBatchSize+1)
+	for i := range ids {
+		ids[i] = "132138279"
+	}
+	req, _ := json.Marshal(map[string][]string{"rncs": ids})
+	if code, _ := do(t, h, http.MethodPost, "/api/checkrnc/batch", string(req)); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("batch of %d = %d, want 413", len(ids), code)
+	}
+}
+
+func TestSearchNamePage(t *testing.T) {
+	h := NewHandler(newTestIndex(t, testRows))
+	code, body := get(t, h, "/api/searchname/CA?limit=1&offset=1")
+	if code != http.StatusOK {
+		t.Fatalf("searchname = %d %s, want 200", code, body)
+	}
+	var got struct {
+		Total, Limit, Offset int
+		Results              []Empresa
+	}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 2 || got.Limit != 1 || got.Offset != 1 || len(got.Results) != 1 {
+		t.Errorf("searchname page = %s, want total 2, limit 1, offset 1 and one result", body)
+	}
+	if code, _ := get(t, h, "/api/searchname/CA?limit=201"); code != http.StatusBadRequest {
+		t.Errorf("limit=201 = %d, want 400", code)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	h := NewHandler(newTestIndex(t, testRows))
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/api/suggest/1321", http.StatusOK, `[{"rnc":"132138279","socialName":"FERRETERIA AMERICANA SRL"}]`},
+		{"/api/suggest/1-01", http.StatusOK, `[{"rnc":"101010632","socialName":"CONSTRUCTORA DEL CARIBE SA"}]`},
+		{"/api/suggest/9", http.StatusOK, `[]`},
+		{"/api/suggest/", http.StatusBadRequest, ""},
+		{"/api/suggest/12a", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		code, body := get(t, h, tt.path)
+		if code != tt.code || (tt.want != "" && strings.TrimSpace(body) != tt.want) {
+			t.Errorf("%s = %d %s, want %d %s", tt.path, code, body, tt.code, tt.want)
+		}
+	}
+}
+
+func TestCheckRNCSuggest(t *testing.T) {
+	h := NewHandler(newTestIndex(t, testRows))
+	tests := []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/api/checkrnc/132138270?suggest=1", http.StatusUnprocessableEntity, `"suggestions":["132138279"]`},
+		{"/api/checkrnc/132138270", http.StatusUnprocessableEntity, `{"error":"invalid RNC format"}`},
+		{"/api/checkrnc/131000012?suggest=1", http.StatusNotFound, `{"error":"This RNC does not exist"}`},
+	}
+	for _, tt := range tests {
+		if code, body := get(t, h, tt.path); code != tt.code || !strings.Contains(body, tt.want) {
+			t.Errorf("GET %s = %d %s, want %d with %s", tt.path, code, body, tt.code, tt.want)
+		}
+	}
+}
+
+// mapStore es un Store de prueba sin LookupMany.
+type mapStore map[string]Empresa
+
+func (m mapStore) Lookup(id string) (Empresa, bool) {
+	emp, ok := m[id]
+	return emp, ok
+}
+func (m mapStore) Len() int                       { return len(m) }
+func (m mapStore) LoadedAt() time.Time            { return time.Time{} }
+func (m mapStore) SourceInfo() (time.Time, int64) { return time.Time{}, 0 }
+func (m mapStore) DataVersion() string            { return "test" }
+func (m mapStore) Duplicates() int                { return 0 }
+func (m mapStore) Reload() error                  { return nil }
+
+func TestFind(t *testing.T) {
+	store := mapStore{"132138279": {RNC: "132138279", SocialName: "FERRETERIA AMERICANA SRL"}}
+	tests := []struct {
+		id      string
+		wantErr error
+	}{
+		{"132138279", nil},
+		{"131000012", ErrNotFound},
+		{"132138270", ErrInvalidRNC},
+		{"12a", ErrMalformedRNC},
+	}
+	for _, tt := range tests {
+		emp, err := Find(store, tt.id)
+		if !errors.Is(err, tt.wantErr) || (err == nil && emp.RNC != tt.id) {
+			t.Errorf("Find(%q) = %+v, %v; want err %v", tt.id, emp, err, tt.wantErr)
+		}
+	}
+
+	found, missing := FindMany(store, []string{"131000012", "132138279", "12a"})
+	if len(found) != 1 || found[0].RNC != "132138279" || !slices.Equal(missing, []string{"131000012", "12a"}) {
+		t.Errorf("FindMany = %+v, missing %v", found, missing)
+	}
+}
+
+func TestExport(t *testing.T) {
+	h := NewHandler(newTestIndex(t, testRows))
+	tests := []struct {
+		name, query string
+		code        int
+		want        []string // líneas de la respuesta
+	}{
+		{"ndjson", "", http.StatusOK, []string{
+			`{"rnc":"132138279","socialName":"FERRETERIA AMERICANA SRL","comercialName":"FERRETODO",`,
+			`{"rnc":"101010632","socialName":"CONSTRUCTORA DEL CARIBE SA","comercialName":"CARIBE",`,
+		}},
+		{"csv", "?format=csv", http.StatusOK, []string{
+			"rnc,socialName,comercialName,status,economicActivity,paymentRegime,category",
+			"132138279,FERRETERIA AMERICANA SRL,FERRETODO,ACTIVO,",
+			"101010632,CONSTRUCTORA DEL CARIBE SA,CARIBE,SUSPENDIDO,",
+		}},
+		{"por estado", "?format=csv&status=suspendido", http.StatusOK, []string{
+			"rnc,socialName,comercialName,status,economicActivity,paymentRegime,category",
+			"101010632,CONSTRUCTORA DEL CARIBE SA,CARIBE,SUSPENDIDO,",
+		}},
+		{"formato inválido", "?format=xml", http.StatusBadRequest, []string{`{`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := get(t, h, "/api/export"+tt.query)
+			lines := strings.Split(strings.TrimSpace(body), "\n")
+			ok := code == tt.code && len(lines) == len(tt.want)
+			for i := 0; ok && i < len(lines); i++ {
+				ok = strings.HasPrefix(lines[i], tt.want[i])
+			}
+			if !ok {
+				t.Errorf("GET /api/export%s = %d\n%s\nwant %d with lines starting %q", tt.query, code, body, tt.code, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	h := NewHandler(newTestIndex(t, testRows))
+	tests := []struct {
+		name, body string
+		code       int
+		want       string
+	}{
+		{"coincide", `{"rnc":"132138279","name":"Ferreteria Americana, S.R.L."}`, http.StatusOK, `"result":"match","match":true,"score":1`},
+		{"nombre comercial", `{"rnc":"1-32-13827-9","name":"ferretodo"}`, http.StatusOK, `"result":"match"`},
+		{"no coincide", `{"rnc":"132138279","name":"CONSTRUCTORA DEL CARIBE"}`, http.StatusOK, `"result":"mismatch","match":false`},
+		{"no existe", `{"rnc":"131000012","name":"ACME"}`, http.StatusOK, `"result":"not-found"`},
+		{"lote", `[{"rnc":"132138279","name":"FERRETERIA AMERICANA"},{"rnc":"101010632","name":"CARIBE"}]`, http.StatusOK, `[{"rnc":"132138279"`},
+		{"sin nombre", `{"rnc":"132138279"}`, http.StatusBadRequest, `"rnc and name are required"`},
+		{"lote sin nombre", `[{"rnc":"132138279","name":"X"},{"rnc":"101010632"}]`, http.StatusBadRequest, `"item 1: rnc and name are required"`},
+		{"JSON inválido", `{"rnc":`, http.StatusBadRequest, `"Invalid JSON body"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := do(t, h, http.MethodPost, "/api/verify", tt.body)
+			if code != tt.code || !strings.Contains(body, tt.want) {
+				t.Errorf("POST /api/verify %s = %d %s, want %d with %s", tt.body, code, body, tt.code, tt.want)
+			}
+		})
+	}
+	if code, _ := get(t, h, "/api/verify"); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/verify = %d, want 405", code)
+	}
+}
+
+// TestHandlerOptions comprueba las opciones de NewHandler y que se puede
+// montar bajo un prefijo.
+func TestHandlerOptions(t *testing.T) {
+	idx := newTestIndex(t, testRows)
+
+	t.Run("WithStoreFunc", func(t *testing.T) {
+		var s Store
+		h := NewHandler(nil, WithStoreFunc(func(context.Context) (Store, error) {
+			if s == nil {
+				return nil, ErrNotReady
+			}
+			return s, nil
+		}))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/checkrnc/132138279", nil))
+		if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "5" ||
+			!strings.Contains(rec.Body.String(), `"index loading"`) {
+			t.Errorf("while not ready = %d, Retry-After %q, %s; want 503 with Retry-After 5", rec.Code, rec.Header().Get("Retry-After"), rec.Body)
+		}
+		if code, _ := get(t, h, "/api/validate/132138279"); code != http.StatusOK {
+			t.Errorf("validate while not ready = %d, want 200", code)
+		}
+		s = idx
+		if code, _ := get(t, h, "/api/checkrnc/132138279"); code != http.StatusOK {
+			t.Errorf("once ready = %d, want 200", code)
+		}
+	})
+
+	t.Run("otro Store", func(t *testing.T) {
+		h := NewHandler(mapStore{"132138279": {RNC: "132138279"}})
+		if code, _ := get(t, h, "/api/checkrnc/132138279"); code != http.StatusOK {
+			t.Errorf("checkrnc = %d, want 200", code)
+		}
+		for _, p := range []string{"/api/searchname/ferreteria", "/api/rncs?status=ACTIVO", "/api/export"} {
+			if code, _ := get(t, h, p); code != http.StatusNotImplemented {
+				t.Errorf("GET %s = %d, want 501", p, code)
+			}
+		}
+	})
+
+	t.Run("WithoutRoutes", func(t *testing.T) {
+		h := NewHandler(idx, WithoutRoutes("/api/reload", "/api/export"))
+		if code, body := do(t, h, http.MethodPost, "/api/reload", ""); code != http.StatusNotFound || !strings.Contains(body, `"not found"`) {
+			t.Errorf("disabled /api/reload = %d %s, want a JSON 404", code, body)
+		}
+		if code, _ := get(t, h, "/api/export"); code != http.StatusNotFound {
+			t.Errorf("disabled /api/export = %d, want 404", code)
+		}
+		if code, _ := get(t, h, "/api/status"); code != http.StatusOK {
+			t.Errorf("/api/status = %d, want 200", code)
+		}
+	})
+
+	t.Run("WithRouteWrapper y WithLookupHook", func(t *testing.T) {
+		var routes, lookups []string
+		h := NewHandler(idx,
+			WithRouteWrapper(func(pattern string, next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					routes = append(routes, pattern)
+					next.ServeHTTP(w, r)
+				})
+			}),
+			WithLookupHook(func(pattern string, hit bool) {
+				lookups = append(lookups, pattern+" "+map[bool]string{true: "hit", false: "miss"}[hit])
+			}),
+		)
+		get(t, h, "/api/checkrnc/132138279")
+		get(t, h, "/api/checkrnc/131000012")
+		get(t, h, "/api/search?q=nada")
+		if want := []string{"/api/checkrnc/", "/api/checkrnc/", "/api/search"}; !slices.Equal(routes, want) {
+			t.Errorf("wrapped routes = %q, want %q", routes, want)
+		}
+		if want := []string{"/api/checkrnc/ hit", "/api/checkrnc/ miss", "/api/search miss"}; !slices.Equal(lookups, want) {
+			t.Errorf("lookup hook = %q, want %q", lookups, want)
+		}
+	})
+
+	t.Run("WithCacheMaxAge", func(t *testing.T) {
+		h := NewHandler(idx, WithCacheMaxAge(time.Hour))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/checkrnc/132138279", nil))
+		if cc := rec.Header().Get("Cache-Control"); !strings.Contains(cc, "max-age=3600") {
+			t.Errorf("Cache-Control = %q, want max-age=3600", cc)
+		}
+	})
+
+	t.Run("bajo un prefijo", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.Handle("/rnc/", http.StripPrefix("/rnc", NewHandler(idx)))
+		code, body := get(t, mux, "/rnc/api/checkrnc/132138279")
+		var emp Empresa
+		if err := json.Unmarshal([]byte(body), &emp); code != http.StatusOK || err != nil || emp.RNC != "132138279" {
+			t.Errorf("GET /rnc/api/checkrnc/132138279 = %d %s", code, body)
+		}
+	})
+}
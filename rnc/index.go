@@ -0,0 +1,718 @@
+package rnc
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"iter"
+	"log/slog"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// Index es el índice en memoria del padrón. Es seguro para uso concurrente:
+// cada carga construye un snapshot inmutable aparte y lo publica de forma
+// atómica, así que las consultas nunca esperan por una recarga.
+type Index struct {
+	path string // origen para Reload; vacío si se construyó desde un io.Reader
+	opts Options
+
+	data atomic.Pointer[snapshot]
+}
+
+// Options ajustan la lectura del CSV. El valor cero es el comportamiento por
+// defecto.
+type Options struct {
+	// Full conserva todas las columnas de cada fila (ver Index.Raw), no solo
+	// las de Empresa. Las filas se copian aparte, en la arena del índice:
+	// cuestan aproximadamente lo que ocupa el CSV, más un string por columna.
+	Full bool
+
+	// Strict hace que un RNC repetido en el CSV sea un error (ErrDuplicateRNC)
+	// en vez de quedarse con la última fila.
+	Strict bool
+
+	// Delimiter separa los campos del CSV; 0 es la coma.
+	Delimiter rune
+
+	// HeaderRows es cuántas filas de cabecera tiene el CSV. 0 detecta si la
+	// primera fila es una cabecera (el comportamiento habitual); N > 0 salta
+	// las N primeras filas y usa la última para ubicar las columnas; NoHeader
+	// trata todas las filas como datos, con las columnas por posición.
+	HeaderRows int
+
+	// Encoding es la codificación del CSV: EncodingAuto (el valor cero),
+	// EncodingUTF8 o EncodingWin1252.
+	Encoding string
+
+	// Fields agrega a Empresa.Extra columnas que no forman parte de Empresa:
+	// nombre -> posición de la columna en la fila (desde 0). Las filas que no
+	// llegan a esa columna la tienen vacía.
+	Fields map[string]int
+}
+
+// NoHeader es el valor de Options.HeaderRows para un CSV sin cabecera.
+const NoHeader = -1
+
+// Valores de Options.Encoding.
+const (
+	EncodingAuto    = ""        // UTF-8 si el primer bloque lo es casi todo, si no Windows-1252 (ver decodeReader)
+	EncodingUTF8    = "utf8"    // UTF-8 siempre; los bytes inválidos se leen como Windows-1252
+	EncodingWin1252 = "win1252" // Windows-1252, la codificación habitual de la DGII
+)
+
+// csvFormat son las opciones que cambian cómo se lee el CSV, no qué se
+// guarda de cada fila. El caché y la base bbolt las guardan para saber si
+// siguen vigentes.
+type csvFormat struct {
+	Delimiter  rune
+	HeaderRows int
+	Encoding   string
+}
+
+func (o Options) format() csvFormat {
+	return csvFormat{Delimiter: o.Delimiter, HeaderRows: o.HeaderRows, Encoding: o.Encoding}
+}
+
+// options devuelve unas Options que solo tienen el formato f.
+func (f csvFormat) options() Options {
+	return Options{Delimiter: f.Delimiter, HeaderRows: f.HeaderRows, Encoding: f.Encoding}
+}
+
+// ErrDuplicateRNC indica que, con Options.Strict, el CSV tenía un RNC
+// repetido.
+var ErrDuplicateRNC = errors.New("duplicate RNC in CSV")
+
+// tables son los datos que produce una lectura del CSV.
+type tables struct {
+	byRNC  map[string]entry // ver put y get
+	byName *nameIndex
+	dict   dict  // textos repetidos de las entradas
+	arena  arena // nombres y claves de las entradas
+
+	duplicates int    // filas cuyo RNC ya estaba en byRNC (gana la última)
+	strict     bool   // Options.Strict: un duplicado es un error
+	version    string // DataVersion ya calculada (caché); vacía = calcularla
+
+	// Solo con Options.Full: nombres de columna normalizados y la fila
+	// original de cada RNC.
+	columns []string
+	rows    map[string][]string
+}
+
+// snapshot son los datos de una carga; no se modifica tras publicarse.
+type snapshot struct {
+	*tables
+	keys     []string // claves de byRNC ordenadas, para búsquedas por prefijo
+	statuses *statusIndex
+	loadedAt time.Time
+	version  string
+
+	// fecha y tamaño del archivo de origen al cargarlo; cero sin archivo
+	srcModTime time.Time
+	srcSize    int64
+}
+
+func newIndex(path string, opts Options, t *tables, src os.FileInfo) *Index {
+	x := &Index{path: path, opts: opts}
+	x.publish(t, src)
+	return x
+}
+
+func (x *Index) publish(t *tables, src os.FileInfo) {
+	keys := make([]string, 0, len(t.byRNC))
+	for k := range t.byRNC {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := &snapshot{
+		tables:   t,
+		keys:     keys,
+		statuses: newStatusIndex(t),
+		loadedAt: time.Now(),
+		version:  t.version,
+	}
+	if s.version == "" {
+		s.version = dataVersion(keys, t)
+	}
+	if src != nil {
+		s.srcModTime, s.srcSize = src.ModTime(), src.Size()
+	}
+	x.data.Store(s)
+}
+
+// dataVersion resume el contenido del padrón en un hash FNV-64a. Depende solo
+// de los datos, no del momento de la carga ni de si vino del CSV o del caché.
+func dataVersion(keys []string, t *tables) string {
+	h := fnv.New64a()
+	for _, k := range keys {
+		hashEmpresa(h, t.empresa(k))
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// hashEmpresa agrega los campos de e a h para dataVersion.
+func hashEmpresa(h hash.Hash64, e Empresa) {
+	for _, f := range [...]string{e.RNC, e.SocialName, e.ComercialName, e.Status, e.EconomicActivity, e.PaymentRegime, e.Category} {
+		io.WriteString(h, f)
+		h.Write([]byte{0})
+	}
+	for _, k := range slices.Sorted(maps.Keys(e.Extra)) {
+		io.WriteString(h, k)
+		h.Write([]byte{0})
+		io.WriteString(h, e.Extra[k])
+		h.Write([]byte{0})
+	}
+}
+
+// NewIndexFromCSV construye un índice a partir del CSV de la DGII en path.
+// Acepta también el CSV comprimido en .gz o dentro de un .zip.
+func NewIndexFromCSV(path string) (*Index, error) {
+	return NewIndexFromCSVOptions(path, Options{})
+}
+
+// NewIndexFromCSVOptions es como NewIndexFromCSV con opciones de lectura, que
+// se mantienen en Reload y Replace.
+func NewIndexFromCSVOptions(path string, opts Options) (*Index, error) {
+	return NewIndexFromCSVContext(context.Background(), path, opts)
+}
+
+// NewIndexFromCSVContext es como NewIndexFromCSVOptions pero deja de leer y
+// devuelve ctx.Err() si ctx se cancela a mitad de la construcción.
+func NewIndexFromCSVContext(ctx context.Context, path string, opts Options) (*Index, error) {
+	idx := &Index{path: path, opts: opts}
+	if err := idx.ReloadContext(ctx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// NewIndexFromReader construye un índice a partir de un CSV ya abierto.
+// El índice resultante no puede recargarse con Reload.
+func NewIndexFromReader(r io.Reader) (*Index, error) {
+	t, err := buildIndex(context.Background(), func() (io.ReadCloser, error) {
+		return io.NopCloser(r), nil
+	}, Options{})
+	if err != nil {
+		return nil, err
+	}
+	return newIndex("", Options{}, t, nil), nil
+}
+
+// Reload vuelve a leer el CSV de origen y reemplaza el contenido del índice.
+// Si la lectura falla, el índice conserva los datos anteriores.
+func (x *Index) Reload() error {
+	return x.ReloadContext(context.Background())
+}
+
+// ReloadContext es Reload con un ctx que interrumpe la lectura; si se
+// cancela, el índice conserva los datos anteriores.
+func (x *Index) ReloadContext(ctx context.Context) error {
+	if x.path == "" {
+		return errors.New("index has no source file to reload")
+	}
+	src, _ := os.Stat(x.path)
+	t, err := buildIndexFile(ctx, x.path, x.opts)
+	if err != nil {
+		return err
+	}
+	x.publish(t, src)
+	return nil
+}
+
+// ErrTooFewEntries indica que Replace rechazó un CSV con menos entradas que
+// las pedidas (por ejemplo, una descarga vacía o truncada).
+var ErrTooFewEntries = errors.New("new CSV has too few entries")
+
+// Replace construye un índice nuevo desde path y, si tiene al menos
+// minEntries entradas, mueve path sobre el CSV de origen del índice y
+// publica los datos nuevos. Ante cualquier error el archivo de origen y el
+// índice quedan intactos; path solo se consume si todo salió bien.
+func (x *Index) Replace(path string, minEntries int) error {
+	return x.ReplaceContext(context.Background(), path, minEntries)
+}
+
+// ReplaceContext es Replace con un ctx que interrumpe la lectura; si se
+// cancela, no se toca nada.
+func (x *Index) ReplaceContext(ctx context.Context, path string, minEntries int) error {
+	if x.path == "" {
+		return errors.New("index has no source file to replace")
+	}
+	src, _ := os.Stat(path) // el rename conserva fecha y tamaño
+	t, err := buildIndexFile(ctx, path, x.opts)
+	if err != nil {
+		return fmt.Errorf("error parsing new CSV: %w", err)
+	}
+	if len(t.byRNC) < minEntries {
+		return fmt.Errorf("%w: %d, expected at least %d", ErrTooFewEntries, len(t.byRNC), minEntries)
+	}
+	if err := os.Rename(path, x.path); err != nil {
+		return err
+	}
+	x.publish(t, src)
+	return nil
+}
+
+// Lookup busca un contribuyente por RNC o cédula. Acepta los formatos con
+// guiones o espacios y tolera la ausencia o sobra del cero inicial.
+func (x *Index) Lookup(rnc string) (Empresa, bool) {
+	return x.data.Load().lookup(rnc)
+}
+
+func (s *snapshot) lookup(rnc string) (Empresa, bool) {
+	norm, ok := Normalize(rnc)
+	if !ok {
+		return Empresa{}, false
+	}
+	for _, k := range variants(norm) {
+		if emp, ok := s.get(k); ok {
+			return emp, true
+		}
+	}
+	return Empresa{}, false
+}
+
+// ErrNoRawData indica que el índice se construyó sin Options.Full.
+var ErrNoRawData = errors.New("index was built without full records")
+
+// Raw devuelve todas las columnas del CSV para rnc, con los valores tal como
+// vienen y las claves de Columns. Requiere Options.Full.
+func (x *Index) Raw(rnc string) (map[string]string, bool, error) {
+	s := x.data.Load()
+	if s.rows == nil {
+		return nil, false, ErrNoRawData
+	}
+	emp, ok := s.lookup(rnc)
+	if !ok {
+		return nil, false, nil
+	}
+	row := s.rows[emp.RNC]
+	out := make(map[string]string, len(row))
+	for i, v := range row {
+		out[columnName(s.columns, i)] = v
+	}
+	return out, true, nil
+}
+
+// Columns devuelve los nombres de columna normalizados ("razon_social") de
+// los datos actuales, o nil sin Options.Full.
+func (x *Index) Columns() []string {
+	return x.data.Load().columns
+}
+
+// LookupMany busca varios RNC sobre una misma versión de los datos. Devuelve
+// los registros encontrados y, en el orden recibido, los que no existen.
+func (x *Index) LookupMany(rncs []string) (found []Empresa, missing []string) {
+	s := x.data.Load()
+	found = make([]Empresa, 0, len(rncs))
+	missing = make([]string, 0)
+	for _, rnc := range rncs {
+		if emp, ok := s.lookup(rnc); ok {
+			found = append(found, emp)
+		} else {
+			missing = append(missing, rnc)
+		}
+	}
+	return found, missing
+}
+
+// Search devuelve la página [offset, offset+limit) de empresas cuyo nombre
+// social o comercial contiene q (sin distinguir mayúsculas ni acentos), y el
+// total de coincidencias. Los resultados conservan los nombres originales.
+func (x *Index) Search(q string, limit, offset int) ([]Empresa, int) {
+	s := x.data.Load()
+	keys, total := s.byName.search(q, limit, offset)
+	out := make([]Empresa, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, s.empresa(k))
+	}
+	return out, total
+}
+
+// SuggestNames devuelve hasta limit empresas cuyo nombre social o comercial
+// empieza por q (sin distinguir mayúsculas ni acentos), con los nombres más
+// cortos primero. Con menos de MinSuggestLen caracteres no devuelve nada.
+func (x *Index) SuggestNames(q string, limit int) []Empresa {
+	s := x.data.Load()
+	keys := s.byName.suggest(q, limit)
+	out := make([]Empresa, len(keys))
+	for i, k := range keys {
+		out[i] = s.empresa(k)
+	}
+	return out
+}
+
+// Prefix devuelve, en orden, hasta limit empresas cuyo RNC empieza por
+// prefix; nil si limit <= 0.
+func (x *Index) Prefix(prefix string, limit int) []Empresa {
+	if limit <= 0 {
+		return nil
+	}
+	s := x.data.Load()
+	out := make([]Empresa, 0, min(limit, 16))
+	for i := sort.SearchStrings(s.keys, prefix); i < len(s.keys) && len(out) < limit; i++ {
+		if !strings.HasPrefix(s.keys[i], prefix) {
+			break
+		}
+		out = append(out, s.empresa(s.keys[i]))
+	}
+	return out
+}
+
+// maxSimilarLen es el largo máximo (en dígitos) para el que Similar genera
+// candidatos: cubre RNC y cédulas con o sin el cero inicial.
+const maxSimilarLen = 11
+
+// Similar devuelve, ordenados, hasta limit RNC del índice a distancia uno de
+// rnc: un dígito cambiado o dos dígitos vecinos intercambiados, los errores
+// típicos al copiar un número. Prueba cada variante en el mapa, sin
+// recorrerlo (9·n+n-1 búsquedas para n dígitos).
+func (x *Index) Similar(rnc string, limit int) []string {
+	norm, ok := Normalize(rnc)
+	if !ok || len(norm) < 2 || len(norm) > maxSimilarLen || limit <= 0 {
+		return nil
+	}
+	s := x.data.Load()
+	seen := make(map[string]bool)
+	var out []string
+	try := func(c []byte) {
+		emp, ok := s.lookup(string(c))
+		if ok && !seen[emp.RNC] {
+			seen[emp.RNC] = true
+			out = append(out, emp.RNC)
+		}
+	}
+	c := []byte(norm)
+	for i := range c {
+		orig := c[i]
+		for d := byte('0'); d <= '9'; d++ {
+			if d != orig {
+				c[i] = d
+				try(c)
+			}
+		}
+		c[i] = orig
+		if i+1 < len(c) && c[i] != c[i+1] {
+			c[i], c[i+1] = c[i+1], c[i]
+			try(c)
+			c[i], c[i+1] = c[i+1], c[i]
+		}
+	}
+	// Si rnc existe (p. ej. con el cero inicial de más) no es una sugerencia
+	if emp, ok := s.lookup(norm); ok && seen[emp.RNC] {
+		out = slices.DeleteFunc(out, func(k string) bool { return k == emp.RNC })
+	}
+	slices.Sort(out)
+	return out[:min(limit, len(out))]
+}
+
+// All recorre todas las empresas en el orden del CSV, sobre una misma
+// versión de los datos aunque haya una recarga a mitad del recorrido.
+func (x *Index) All() iter.Seq[Empresa] {
+	s := x.data.Load()
+	return func(yield func(Empresa) bool) {
+		for _, k := range s.byName.rncs {
+			if !yield(s.empresa(k)) {
+				return
+			}
+		}
+	}
+}
+
+// Len devuelve el número de entradas del índice.
+func (x *Index) Len() int {
+	return len(x.data.Load().byRNC)
+}
+
+// Duplicates devuelve cuántas filas del CSV repetían el RNC de una fila
+// anterior y la reemplazaron.
+func (x *Index) Duplicates() int {
+	return x.data.Load().duplicates
+}
+
+// LoadedAt devuelve el momento en que se cargaron los datos actuales.
+func (x *Index) LoadedAt() time.Time {
+	return x.data.Load().loadedAt
+}
+
+// SourceInfo devuelve la fecha de modificación y el tamaño que tenía el
+// archivo de origen al cargar los datos actuales (cero si no hay archivo).
+func (x *Index) SourceInfo() (modTime time.Time, size int64) {
+	s := x.data.Load()
+	return s.srcModTime, s.srcSize
+}
+
+// DataVersion identifica el contenido de los datos actuales: cambia cuando
+// una recarga trae datos distintos y se mantiene si son los mismos.
+func (x *Index) DataVersion() string {
+	return x.data.Load().version
+}
+
+// buildIndexFile construye el índice desde path, que puede ser .csv, .zip
+// o .gz.
+func buildIndexFile(ctx context.Context, path string, opts Options) (*tables, error) {
+	return buildIndex(ctx, func() (io.ReadCloser, error) { return openCSV(path) }, opts)
+}
+
+// buildIndex lee el CSV fila a fila, sin cargarlo entero en memoria. Si ctx
+// se cancela devuelve ctx.Err().
+func buildIndex(ctx context.Context, open func() (io.ReadCloser, error), opts Options) (*tables, error) {
+	t := newTables(opts)
+	if err := readCSV(ctx, open, opts, t); err != nil {
+		return nil, err
+	}
+	if t.duplicates > 0 {
+		t.byName.dropSuperseded()
+	}
+	t.byName.finish()
+	if t.duplicates > 0 {
+		slog.Warn("CSV has duplicate RNCs, keeping the last row of each", "duplicates", t.duplicates)
+	}
+	slog.Info("Index loaded", "entries", len(t.byRNC))
+	return t, nil
+}
+
+func newTables(opts Options) *tables {
+	t := &tables{byRNC: make(map[string]entry), byName: newNameIndex(0), strict: opts.Strict}
+	if opts.Full {
+		t.rows = make(map[string][]string)
+	}
+	return t
+}
+
+func (t *tables) header(row []string) {
+	if t.rows != nil {
+		t.columns = columnNames(row)
+	}
+}
+
+func (t *tables) skipped(int) {}
+
+func (t *tables) add(rows []parsedRow) error {
+	for _, p := range rows {
+		if err := t.countDuplicate(p.emp.RNC); err != nil {
+			return err
+		}
+		k := t.put(p.emp)
+		t.byName.addFolded(k, p.social, p.comercial)
+		if t.rows != nil {
+			t.rows[k] = t.copyRow(p.row)
+		}
+	}
+	return nil
+}
+
+// countDuplicate anota si rnc ya está en el índice, antes de agregarlo.
+func (t *tables) countDuplicate(rnc string) error {
+	if _, dup := t.byRNC[rnc]; !dup {
+		return nil
+	}
+	if t.strict {
+		return fmt.Errorf("%w: %s", ErrDuplicateRNC, rnc)
+	}
+	t.duplicates++
+	return nil
+}
+
+// rowSink recibe lo que produce scanCSV: la cabecera, si la hay, las filas
+// convertidas por tramos, en el orden del CSV, y al final cuántas filas se
+// descartaron por tener menos columnas de las necesarias.
+type rowSink interface {
+	header(row []string)
+	add(rows []parsedRow) error
+	skipped(n int)
+}
+
+// readCSV vuelca en sink el CSV de open, con las filas convertidas según
+// opts. Con EncodingAuto la codificación se decide con el primer bloque (ver
+// decodeReader).
+func readCSV(ctx context.Context, open func() (io.ReadCloser, error), opts Options, sink rowSink) error {
+	f, err := open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader
+	var repair bool
+	switch opts.Encoding {
+	case EncodingUTF8:
+		r, repair = f, true
+	case EncodingWin1252:
+		r = transform.NewReader(f, charmap.Windows1252.NewDecoder())
+	default:
+		r, repair = decodeReader(f)
+	}
+	return scanCSV(ctx, newCSVReader(r, opts.Delimiter), repair, opts, sink)
+}
+
+// shardRows es cuántas filas convierte cada tarea de scanCSV.
+const shardRows = 4096
+
+// scanCSV lee r y entrega las filas a sink a medida que lee. Las filas se
+// reparten en tramos de shardRows entre GOMAXPROCS workers, que hacen la
+// conversión a Empresa y el Fold de los nombres; sink recibe los tramos en
+// el orden del CSV, así que ante RNC repetidos gana la última fila, como en
+// una lectura secuencial. Con repair los campos pasan por repairRow. Entre
+// tramo y tramo se mira ctx: si se canceló, la lectura para y devuelve
+// ctx.Err().
+func scanCSV(ctx context.Context, r *csv.Reader, repair bool, opts Options, sink rowSink) error {
+	var row []string
+	var err error
+	// con HeaderRows > 1 se descartan las primeras: la última ubica las
+	// columnas
+	for range max(opts.HeaderRows, 1) {
+		if row, err = r.Read(); err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if repair {
+			repairRow(row)
+		}
+	}
+	var cols columnMap
+	var hasHeader bool
+	switch {
+	case opts.HeaderRows == NoHeader:
+		cols = positionalColumns
+	case opts.HeaderRows > 0:
+		cols, _ = detectColumns(row)
+		hasHeader = true
+	default:
+		cols, hasHeader = detectColumns(row)
+	}
+	if hasHeader {
+		sink.header(row)
+		row = nil
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	jobs := make(chan *shard)
+	pending := make(chan *shard, 2*workers) // limita los tramos en memoria
+	stop := make(chan struct{})
+	var readErr error
+	short := 0
+	for range workers {
+		go func() {
+			for sh := range jobs {
+				sh.convert(cols, repair, opts)
+			}
+		}()
+	}
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		batch := make([][]string, 0, shardRows)
+		send := func() bool {
+			sh := &shard{rows: batch, done: make(chan struct{})}
+			select {
+			case pending <- sh:
+			case <-stop:
+				return false
+			}
+			jobs <- sh
+			batch = make([][]string, 0, shardRows)
+			return true
+		}
+		for ; ; row, err = r.Read() {
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+			if row == nil { // la cabecera
+				continue
+			}
+			if len(row) < cols.minLen() {
+				short++
+				continue
+			}
+			if batch = append(batch, row); len(batch) == shardRows && !send() {
+				return
+			}
+		}
+		if len(batch) > 0 {
+			send()
+		}
+	}()
+
+	for sh := range pending {
+		<-sh.done
+		err := ctx.Err()
+		if err == nil {
+			err = sink.add(sh.out)
+		}
+		if err != nil {
+			close(stop)
+			for range pending { // esperar a que el lector termine
+			}
+			return err
+		}
+	}
+	// pending se cierra cuando el lector termina: readErr y short ya no
+	// cambian
+	sink.skipped(short)
+	return readErr
+}
+
+// shard es un tramo de filas del CSV; done se cierra cuando out está listo.
+type shard struct {
+	rows [][]string
+	out  []parsedRow
+	done chan struct{}
+}
+
+// parsedRow es una fila convertida, con los nombres ya pasados por Fold.
+type parsedRow struct {
+	emp               Empresa
+	social, comercial string
+	row               []string // solo con Options.Full
+}
+
+func (sh *shard) convert(cols columnMap, repair bool, opts Options) {
+	defer close(sh.done)
+	sh.out = make([]parsedRow, 0, len(sh.rows))
+	for _, row := range sh.rows {
+		if repair {
+			repairRow(row)
+		}
+		emp := mapToAPI(empresaRaw{
+			RNC:                cols.get(row, cols.rnc),
+			RazonSocial:        cols.get(row, cols.razonSocial),
+			NombreComercial:    cols.get(row, cols.nombreComercial),
+			Categoria:          cols.get(row, cols.categoria),
+			RegimenPago:        cols.get(row, cols.regimenPago),
+			Estado:             cols.get(row, cols.estado),
+			ActividadEconomica: cols.get(row, cols.actividad),
+		})
+		if len(opts.Fields) > 0 {
+			emp.Extra = make(map[string]string, len(opts.Fields))
+			for name, i := range opts.Fields {
+				emp.Extra[name] = cols.get(row, i)
+			}
+		}
+		p := parsedRow{emp: emp, social: Fold(emp.SocialName), comercial: Fold(emp.ComercialName)}
+		if opts.Full {
+			p.row = row // tables.add copia los campos a la arena
+		}
+		sh.out = append(sh.out, p)
+	}
+	sh.rows = nil
+}
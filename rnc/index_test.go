@@ -0,0 +1,825 @@
+package rnc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testHeader es la cabecera del padrón de la DGII.
+const testHeader = "RNC,RAZÓN SOCIAL,NOMBRE COMERCIAL,ACTIVIDAD ECONÓMICA,FECHA DE INICIO,ESTADO,RÉGIMEN DE PAGO\n"
+
+// newTestIndex construye un índice con las filas de rows bajo testHeader.
+func newTestIndex(t testing.TB, rows string) *Index {
+	t.Helper()
+	idx, err := NewIndexFromReader(strings.NewReader(testHeader + rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+// syntheticCSV genera un padrón de n filas con la forma del de la DGII:
+// nombres distintos y pocos valores de estado, actividad y régimen.
+func syntheticCSV(n int) string {
+	statuses := []string{"ACTIVO", "SUSPENDIDO", "DADO DE BAJA", "CESE TEMPORAL"}
+	regimes := []string{"NORMAL", "RST", "PST"}
+	var sb strings.Builder
+	sb.WriteString(testHeader)
+	for i := range n {
+		fmt.Fprintf(&sb, "%09d,EMPRESA NUMERO %d SRL,COMERCIAL %d,ACTIVIDAD ECONOMICA %d,%02d/%02d/%d,%s,%s\n",
+			100000000+i, i, i, i%300, i%28+1, i%12+1, 1990+i%35, statuses[i%len(statuses)], regimes[i%len(regimes)])
+	}
+	return sb.String()
+}
+
+// BenchmarkNewIndex mide la construcción del índice a partir de un CSV de
+// 100.000 filas, con un solo worker y con GOMAXPROCS; B/op refleja lo que se
+// reserva al leerlo:
+//
+//	go test ./rnc -run '^$' -bench NewIndex -benchmem
+func BenchmarkNewIndex(b *testing.B) {
+	data := []byte(syntheticCSV(100_000))
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	for _, procs := range slices.Compact([]int{1, runtime.GOMAXPROCS(0)}) {
+		b.Run(fmt.Sprintf("procs=%d", procs), func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := NewIndexFromReader(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkIndexHeap mide con runtime.ReadMemStats, tras un GC, el heap que
+// retiene un índice de 200.000 filas:
+//
+//	go test ./rnc -run '^$' -bench IndexHeap -benchtime 3x
+func BenchmarkIndexHeap(b *testing.B) {
+	const rows = 200_000
+	data := syntheticCSV(rows)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler)) // sin "Index loaded" en cada vuelta
+	var held uint64
+	for range b.N {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		idx, err := NewIndexFromReader(strings.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		runtime.KeepAlive(idx)
+		held = after.HeapAlloc - before.HeapAlloc
+	}
+	b.ReportMetric(float64(held)/(1<<20), "heap-MB")
+	b.ReportMetric(float64(held)/rows, "heap-B/entry")
+}
+
+// BenchmarkLoadCache mide la carga del mismo padrón desde el snapshot
+// binario, que evita parsear el CSV y pasar los nombres por Fold; compárese
+// con BenchmarkNewIndex:
+//
+//	go test ./rnc -run '^$' -bench 'NewIndex|LoadCache' -benchmem
+func BenchmarkLoadCache(b *testing.B) {
+	csvPath, cachePath := benchSnapshot(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := LoadCache(csvPath, cachePath); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLookup mide consultas por RNC concurrentes sobre un índice de
+// 100.000 entradas, con formatos variados como llegan a la API, tanto
+// construido desde el CSV como cargado del snapshot.
+func BenchmarkLookup(b *testing.B) {
+	queries := []string{"100000000", "1-00-05000-0", "100099999", "000100042", "199999999"}
+	for _, bi := range benchIndexes(b) {
+		b.Run(bi.name, func(b *testing.B) {
+			idx := bi.idx
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					idx.Lookup(queries[i%len(queries)])
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkSearch mide búsquedas por nombre concurrentes (una página de 20)
+// sobre los mismos índices.
+func BenchmarkSearch(b *testing.B) {
+	queries := []string{"numero 4242", "comercial 9", "srl", "no existe"}
+	for _, bi := range benchIndexes(b) {
+		b.Run(bi.name, func(b *testing.B) {
+			idx := bi.idx
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					idx.Search(queries[i%len(queries)], 20, 0)
+				}
+			})
+		})
+	}
+}
+
+// benchSnapshot escribe el CSV de 100.000 filas de los benchmarks y su
+// snapshot, y devuelve las dos rutas.
+func benchSnapshot(b *testing.B) (csvPath, cachePath string) {
+	b.Helper()
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	dir := b.TempDir()
+	csvPath, cachePath = filepath.Join(dir, "rncs.csv"), filepath.Join(dir, "rncs.idx")
+	if err := os.WriteFile(csvPath, []byte(syntheticCSV(100_000)), 0o644); err != nil {
+		b.Fatal(err)
+	}
+	idx, err := NewIndexFromCSV(csvPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := idx.SaveCache(cachePath); err != nil {
+		b.Fatal(err)
+	}
+	return csvPath, cachePath
+}
+
+// benchIndex es un índice de los benchmarks de consulta y su origen.
+type benchIndex struct {
+	name string
+	idx  *Index
+}
+
+// benchIndexes construye los índices de 100.000 filas de los benchmarks de
+// consulta: uno desde el CSV y otro desde el snapshot.
+func benchIndexes(b *testing.B) []benchIndex {
+	csvPath, cachePath := benchSnapshot(b)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	fromCSV, err := NewIndexFromCSV(csvPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	fromCache, err := LoadCache(csvPath, cachePath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return []benchIndex{{"source=csv", fromCSV}, {"source=snapshot", fromCache}}
+}
+
+// BenchmarkScanCSV mide solo la lectura de filas con newCSVReader, sin
+// construir el índice:
+//
+//	go test ./rnc -run '^$' -bench ScanCSV
+func BenchmarkScanCSV(b *testing.B) {
+	data := []byte(syntheticCSV(100_000))
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for b.Loop() {
+		r := newCSVReader(bytes.NewReader(data), 0)
+		for {
+			if _, err := r.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// TestEncoding comprueba que un CSV en Windows-1252 se decodifica, tanto si
+// se detecta en el primer bloque como si el primer texto no ASCII aparece
+// más adelante, y que en un CSV UTF-8 con alguna fila en Windows-1252 se
+// reparan esas filas sin estropear las demás.
+func TestEncoding(t *testing.T) {
+	row := "132138279,COMPA\xd1IA DOMINICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	utf8Rows := "101010632,FERRETERÍA PEÑA SA,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n" +
+		"131000012,CONSTRUCCIONES ÉXITO SRL,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n"
+	tests := []struct {
+		name, csv string
+	}{
+		{"primer bloque", testHeader + row},
+		{"tras el primer bloque", syntheticCSV(sniffSize/50) + row}, // filas de más de 50 bytes
+		{"mixto", testHeader + utf8Rows + row},
+		{"mixto, tras el primer bloque", syntheticCSV(sniffSize/50) + utf8Rows + row},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx, err := NewIndexFromReader(strings.NewReader(tt.csv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			emp, ok := idx.Lookup("132138279")
+			if !ok || emp.SocialName != "COMPAÑIA DOMINICANA SRL" {
+				t.Errorf("Lookup = %q, %v; want COMPAÑIA DOMINICANA SRL", emp.SocialName, ok)
+			}
+			if emp, ok := idx.Lookup("101010632"); strings.Contains(tt.csv, "PEÑA") && (!ok || emp.SocialName != "FERRETERÍA PEÑA SA") {
+				t.Errorf("UTF-8 row = %q, %v; want FERRETERÍA PEÑA SA", emp.SocialName, ok)
+			}
+		})
+	}
+}
+
+func TestRepairUTF8(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"CAÑA", "CAÑA"},
+		{"CA\xd1A", "CAÑA"},
+		{"\xc9XITO Y PE\xd1A", "ÉXITO Y PEÑA"},
+		{"PEÑA Y CA\xd1A", "PEÑA Y CAÑA"},
+		{"\x93COMILLAS\x94", "\u201cCOMILLAS\u201d"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := repairUTF8(tt.in); got != tt.want {
+			t.Errorf("repairUTF8(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMostlyUTF8(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want bool
+	}{
+		{"ASCII", "RNC,NOMBRE\n", true},
+		{"UTF-8", "PEÑA,ÉXITO\n", true},
+		{"Windows-1252", "PE\xd1A,\xc9XITO\n", false},
+		{"UTF-8 con un byte suelto", "PEÑA,ÉXITO,CA\xd1A\n", true},
+		{"runa cortada al final", "PEÑA,\xc3", true},
+	}
+	for _, tt := range tests {
+		if got := mostlyUTF8([]byte(tt.in)); got != tt.want {
+			t.Errorf("%s: mostlyUTF8(%q) = %v, want %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestEncodingOption comprueba que Options.Encoding fuerza la codificación
+// en vez de detectarla.
+func TestEncodingOption(t *testing.T) {
+	const win1252 = "132138279,COMPA\xd1IA DOMINICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	const utf8 = "132138279,COMPAÑIA DOMINICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	tests := []struct {
+		name, row, encoding, want string
+	}{
+		{"auto, Windows-1252", win1252, EncodingAuto, "COMPAÑIA DOMINICANA SRL"},
+		{"auto, UTF-8", utf8, EncodingAuto, "COMPAÑIA DOMINICANA SRL"},
+		{"win1252", win1252, EncodingWin1252, "COMPAÑIA DOMINICANA SRL"},
+		{"utf8", utf8, EncodingUTF8, "COMPAÑIA DOMINICANA SRL"},
+		{"utf8 sobre Windows-1252: bytes reparados", win1252, EncodingUTF8, "COMPAÑIA DOMINICANA SRL"},
+		{"win1252 sobre UTF-8", utf8, EncodingWin1252, "COMPAÃ\u2018IA DOMINICANA SRL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rncs.csv")
+			if err := os.WriteFile(path, []byte(testHeader+tt.row), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			idx, err := NewIndexFromCSVOptions(path, Options{Encoding: tt.encoding})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if emp, ok := idx.Lookup("132138279"); !ok || emp.SocialName != tt.want {
+				t.Errorf("Lookup = %q, %v; want %q", emp.SocialName, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"131000012,ACME SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n"+
+		"101000122,OMEGA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"todos", "", 10, []string{"101000122", "101010632", "131000012", "132138279"}},
+		{"prefijo común", "1010", 10, []string{"101000122", "101010632"}},
+		{"RNC completo", "132138279", 10, []string{"132138279"}},
+		{"entre dos claves", "1311", 10, nil},
+		{"después de la última", "9", 10, nil},
+		{"limit corta", "1", 3, []string{"101000122", "101010632", "131000012"}},
+		{"limit cero", "1", 0, nil},
+		{"limit negativo", "1", -1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, emp := range idx.Prefix(tt.prefix, tt.limit) {
+				got = append(got, emp.RNC)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Prefix(%q, %d) = %v, want %v", tt.prefix, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormalizedKeys comprueba que un RNC escrito con guiones o espacios en
+// el CSV se indexa normalizado y se encuentra con cualquier formato.
+func TestNormalizedKeys(t *testing.T) {
+	idx := newTestIndex(t, " 1-32-13827-9 ,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+	for _, q := range []string{"132138279", "1-32-13827-9"} {
+		emp, ok := idx.Lookup(q)
+		if !ok || emp.RNC != "132138279" {
+			t.Errorf("Lookup(%q) = %q, %v; want 132138279", q, emp.RNC, ok)
+		}
+	}
+	if got := idx.Prefix("1321", 10); len(got) != 1 {
+		t.Errorf("Prefix(1321) = %v, want the normalized key", got)
+	}
+}
+
+// TestLookupFormats comprueba que las formas impresas por la DGII en
+// facturas y comprobantes encuentran el mismo registro.
+func TestLookupFormats(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"00113918205,JUAN PEREZ,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"132138279", "132138279"},
+		{"1-32-13827-9", "132138279"},
+		{"132-13827-9", "132138279"},
+		{"001-1391820-5", "00113918205"},
+		{"001 1391820 5", "00113918205"},
+		{"0113918205", "00113918205"}, // sin el cero inicial
+	}
+	for _, tt := range tests {
+		emp, ok := idx.Lookup(tt.in)
+		if !ok || emp.RNC != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q", tt.in, emp.RNC, ok, tt.want)
+		}
+	}
+	if _, ok := idx.Lookup("1-32-13827-X"); ok {
+		t.Error("Lookup(1-32-13827-X) found a record")
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"132138278,FERRETERIA NACIONAL SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n")
+
+	tests := []struct {
+		name, rnc string
+		limit     int
+		want      []string
+	}{
+		{"dígito cambiado", "132138270", 10, []string{"132138278", "132138279"}},
+		{"limit", "132138270", 1, []string{"132138278"}},
+		{"transposición", "101010623", 10, []string{"101010632"}},
+		{"con guiones", "1-01-01062-3", 10, []string{"101010632"}},
+		{"existe: solo los vecinos", "132138279", 10, []string{"132138278"}},
+		{"lejos de todos", "401506254", 10, nil},
+		{"no es un número", "abc", 10, nil},
+	}
+	for _, tt := range tests {
+		if got := idx.Similar(tt.rnc, tt.limit); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Similar(%q, %d) = %v, want %v", tt.name, tt.rnc, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// TestParseShards comprueba que un CSV de varios tramos conserva el orden
+// del archivo y que, con un RNC repetido en tramos distintos, gana la última
+// fila como en una lectura secuencial.
+func TestParseShards(t *testing.T) {
+	n := 3*shardRows + 10
+	csv := syntheticCSV(n) + "100000000,EMPRESA REPETIDA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	idx, err := NewIndexFromReader(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != n {
+		t.Errorf("Len = %d, want %d", idx.Len(), n)
+	}
+	if emp, _ := idx.Lookup("100000000"); emp.SocialName != "EMPRESA REPETIDA SRL" {
+		t.Errorf("repeated RNC = %q, want the last row", emp.SocialName)
+	}
+	// la fila repetida queda en la posición de la última, al final
+	i := 1
+	for emp := range idx.All() {
+		if want := fmt.Sprintf("%09d", 100000000+i%n); emp.RNC != want {
+			t.Fatalf("All()[%d] = %s, want %s", i-1, emp.RNC, want)
+		}
+		i++
+	}
+	if i-1 != n {
+		t.Errorf("All() listed %d entries, want %d", i-1, n)
+	}
+}
+
+// TestDuplicateRNC comprueba que un RNC repetido queda una sola vez en el
+// recorrido, los conteos por estado y las búsquedas por nombre, con los
+// datos de la última fila.
+func TestDuplicateRNC(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"131000012,ACME SRL,ACME,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101000122,FERRETERIA OMEGA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"131000012,ZETA SRL,ZETA,COMERCIO,01/01/2000,SUSPENDIDO,NORMAL\n")
+
+	if got := idx.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+	if got := idx.Duplicates(); got != 1 {
+		t.Errorf("Duplicates() = %d, want 1", got)
+	}
+
+	var all []string
+	for emp := range idx.All() {
+		all = append(all, emp.RNC+" "+emp.SocialName)
+	}
+	// gana la última fila, en su posición del CSV
+	if want := []string{"101000122 FERRETERIA OMEGA SRL", "131000012 ZETA SRL"}; !slices.Equal(all, want) {
+		t.Errorf("All() = %q, want %q", all, want)
+	}
+
+	wantStatuses := []StatusCount{{Status: "ACTIVO", Count: 1}, {Status: "SUSPENDIDO", Count: 1}}
+	if got := idx.Statuses(); !slices.Equal(got, wantStatuses) {
+		t.Errorf("Statuses() = %v, want %v", got, wantStatuses)
+	}
+
+	tests := []struct {
+		q     string
+		total int
+		rncs  []string
+	}{
+		{"SRL", 2, []string{"101000122", "131000012"}},
+		{"zeta", 1, []string{"131000012"}},
+		{"ACME", 0, nil}, // el nombre reemplazado ya no se encuentra
+	}
+	for _, tt := range tests {
+		res, total := idx.Search(tt.q, 10, 0)
+		var rncs []string
+		for _, emp := range res {
+			rncs = append(rncs, emp.RNC)
+		}
+		if total != tt.total || !slices.Equal(rncs, tt.rncs) {
+			t.Errorf("Search(%q) = %v (total %d), want %v (total %d)", tt.q, rncs, total, tt.rncs, tt.total)
+		}
+	}
+
+	if got := idx.SuggestNames("acm", 10); len(got) != 0 {
+		t.Errorf("SuggestNames(acm) = %v, want none", got)
+	}
+}
+
+// TestRaw comprueba que con Options.Full se conservan todas las columnas,
+// con las claves de la cabecera normalizadas o column_N si no la hay, y que
+// sin Options.Full Raw devuelve ErrNoRawData.
+func TestRaw(t *testing.T) {
+	const row = "132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	tests := []struct {
+		name, csv, col, want string
+	}{
+		{"con cabecera", testHeader + row, "fecha_de_inicio", "01/01/2000"},
+		{"con cabecera, acentos", testHeader + row, "regimen_de_pago", "NORMAL"},
+		{"sin cabecera", row, "column_5", "01/01/2000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rncs.csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			idx, err := NewIndexFromCSVOptions(path, Options{Full: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, ok, err := idx.Raw("1-32-13827-9")
+			if err != nil || !ok || len(raw) != 7 || raw[tt.col] != tt.want {
+				t.Errorf("Raw = %v, %v, %v; want 7 columns with %s = %q", raw, ok, err, tt.col, tt.want)
+			}
+			if _, ok, err := idx.Raw("101010632"); ok || err != nil {
+				t.Errorf("Raw of a missing RNC = %v, %v", ok, err)
+			}
+		})
+	}
+
+	idx := newTestIndex(t, row)
+	if _, _, err := idx.Raw("132138279"); !errors.Is(err, ErrNoRawData) {
+		t.Errorf("Raw without Options.Full: err = %v, want ErrNoRawData", err)
+	}
+}
+
+// TestDuplicates comprueba que un RNC repetido se cuenta igual al leer el
+// CSV, desde el caché y en la base bbolt, y que con Options.Strict es un
+// error que en Reload conserva los datos anteriores.
+func TestDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rncs.csv")
+	const one = "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	const dup = one + "1-32-13827-9,FERRETERIA AMERICANA SA,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	if err := os.WriteFile(path, []byte(testHeader+dup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := NewIndexFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emp, _ := idx.Lookup("132138279"); idx.Duplicates() != 1 || emp.SocialName != "FERRETERIA AMERICANA SA" {
+		t.Errorf("Duplicates = %d, Lookup = %q; want 1 and the last row", idx.Duplicates(), emp.SocialName)
+	}
+	cachePath := filepath.Join(dir, "rncs.idx")
+	if err := idx.SaveCache(cachePath); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := LoadCache(path, cachePath); err != nil || cached.Duplicates() != 1 {
+		t.Errorf("LoadCache: err = %v, want 1 duplicate", err)
+	}
+	if _, err := LoadCacheOptions(path, cachePath, Options{Strict: true}); !errors.Is(err, ErrDuplicateRNC) {
+		t.Errorf("strict LoadCache: err = %v, want ErrDuplicateRNC", err)
+	}
+	db, err := OpenBolt(path, filepath.Join(dir, "rncs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if db.Duplicates() != 1 {
+		t.Errorf("BoltStore.Duplicates = %d, want 1", db.Duplicates())
+	}
+
+	if _, err := NewIndexFromCSVOptions(path, Options{Strict: true}); !errors.Is(err, ErrDuplicateRNC) {
+		t.Errorf("strict load: err = %v, want ErrDuplicateRNC", err)
+	}
+	if err := os.WriteFile(path, []byte(testHeader+one), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strict, err := NewIndexFromCSVOptions(path, Options{Strict: true})
+	if err != nil || strict.Duplicates() != 0 {
+		t.Fatalf("strict load without duplicates: err = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(testHeader+dup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := strict.Reload(); !errors.Is(err, ErrDuplicateRNC) || strict.Len() != 1 {
+		t.Errorf("strict Reload: err = %v, %d entries; want ErrDuplicateRNC and the old data", err, strict.Len())
+	}
+}
+
+// TestFields comprueba que Options.Fields llena Empresa.Extra, con las
+// columnas que faltan vacías, y que forma parte de DataVersion.
+func TestFields(t *testing.T) {
+	const rows = "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	idx, err := NewIndexFromReader(strings.NewReader(testHeader + rows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "rncs.csv")
+	if err := os.WriteFile(path, []byte(testHeader+rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	extra, err := NewIndexFromCSVOptions(path, Options{Fields: map[string]int{"fecha_inicio": 4, "lejos": 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	emp, _ := extra.Lookup("132138279")
+	if want := map[string]string{"fecha_inicio": "01/01/2000", "lejos": ""}; !maps.Equal(emp.Extra, want) {
+		t.Errorf("Extra = %v, want %v", emp.Extra, want)
+	}
+	if plain, _ := idx.Lookup("132138279"); plain.Extra != nil {
+		t.Errorf("Extra without Options.Fields = %v, want nil", plain.Extra)
+	}
+	if extra.DataVersion() == idx.DataVersion() {
+		t.Error("DataVersion ignores Extra")
+	}
+}
+
+// TestCSVLayout comprueba Options.Delimiter y Options.HeaderRows, tanto al
+// construir el índice como en ValidateCSV.
+func TestCSVLayout(t *testing.T) {
+	const row = "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	// la primera fila no es una cabecera, pero su RNC no son solo dígitos
+	const dashed = "1-32-13827-9,FERRETERIA AMERICANA SRL,,COMERCIO,ACTIVO\n" +
+		"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,ACTIVO\n"
+	tests := []struct {
+		name    string
+		csv     string
+		opts    Options
+		entries int
+		found   bool // 132138279 está en el índice
+	}{
+		{"coma", testHeader + row, Options{}, 1, true},
+		{"punto y coma", strings.ReplaceAll(testHeader+row, ",", ";"), Options{Delimiter: ';'}, 1, true},
+		{"tabulador", strings.ReplaceAll(testHeader+row, ",", "\t"), Options{Delimiter: '\t'}, 1, true},
+		{"separador equivocado", strings.ReplaceAll(testHeader+row, ",", ";"), Options{}, 0, false},
+		{"auto toma la primera fila por cabecera", dashed, Options{}, 1, false},
+		{"NoHeader", dashed, Options{HeaderRows: NoHeader}, 2, true},
+		{"dos filas de cabecera", "PADRON DGII\nRAZÓN SOCIAL,RNC\nFERRETERIA AMERICANA SRL,132138279\n", Options{HeaderRows: 2}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rncs.csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			idx, err := NewIndexFromCSVOptions(path, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			emp, ok := idx.Lookup("132138279")
+			if idx.Len() != tt.entries || ok != tt.found || (ok && emp.SocialName != "FERRETERIA AMERICANA SRL") {
+				t.Errorf("Len = %d, Lookup = %+v, %v; want %d entries, found %v", idx.Len(), emp, ok, tt.entries, tt.found)
+			}
+			if rep, err := ValidateCSV(path, tt.opts); err != nil || rep.Entries != tt.entries {
+				t.Errorf("ValidateCSV = %+v, %v; want %d entries", rep, err, tt.entries)
+			}
+		})
+	}
+}
+
+// TestReplaceTooFew comprueba que Replace rechaza un CSV con menos entradas
+// de las pedidas sin tocar el archivo de origen ni los datos.
+func TestReplaceTooFew(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rncs.csv")
+	const row = "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	if err := os.WriteFile(path, []byte(testHeader+row), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := NewIndexFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "new.csv")
+	if err := os.WriteFile(empty, []byte(testHeader), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Replace(empty, 1); !errors.Is(err, ErrTooFewEntries) {
+		t.Errorf("Replace(empty) = %v, want ErrTooFewEntries", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != testHeader+row || idx.Len() != 1 {
+		t.Errorf("rejected Replace changed the source (%q) or the data (%d entries)", got, idx.Len())
+	}
+}
+
+// TestReloadWhileLookingUp recarga el índice una y otra vez mientras otras
+// goroutines consultan; cada consulta debe ver una versión completa de los
+// datos. Tiene sentido con go test -race.
+func TestReloadWhileLookingUp(t *testing.T) {
+	versions := []string{
+		testHeader + "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n" +
+			"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n",
+		testHeader + "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,SUSPENDIDO,NORMAL\n" +
+			"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,SUSPENDIDO,NORMAL\n",
+	}
+	path := filepath.Join(t.TempDir(), "rncs.csv")
+	write := func(v int) {
+		// se reemplaza el archivo entero para que Reload nunca lea uno a medias
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(versions[v]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(0)
+	idx, err := NewIndexFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				a, okA := idx.Lookup("132138279")
+				if !okA || a.SocialName != "FERRETERIA AMERICANA SRL" {
+					errs <- fmt.Errorf("Lookup = %+v, %v", a, okA)
+					return
+				}
+				// LookupMany resuelve el lote sobre una misma versión
+				found, missing := idx.LookupMany([]string{"132138279", "101010632"})
+				if len(found) != 2 || len(missing) != 0 || found[0].Status != found[1].Status {
+					errs <- fmt.Errorf("LookupMany = %+v, missing %v", found, missing)
+					return
+				}
+				if _, total := idx.Search("srl", 10, 0); total != 1 {
+					errs <- fmt.Errorf("Search total = %d", total)
+					return
+				}
+			}
+		}()
+	}
+	for i := range 50 {
+		write(i % 2)
+		if err := idx.Reload(); err != nil {
+			t.Error(err)
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// cancelAfter es un contexto que se cancela solo en la vuelta n en que se
+// consulta Err, para cortar una construcción en un punto conocido.
+type cancelAfter struct {
+	context.Context
+	cancel context.CancelFunc
+	n      int
+	calls  int
+}
+
+func newCancelAfter(n int) *cancelAfter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &cancelAfter{Context: ctx, cancel: cancel, n: n}
+}
+
+func (c *cancelAfter) Err() error {
+	if c.calls++; c.calls == c.n {
+		c.cancel()
+	}
+	return c.Context.Err()
+}
+
+func TestBuildCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	path := filepath.Join(t.TempDir(), "rncs.csv")
+	if err := os.WriteFile(path, []byte(syntheticCSV(10)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if idx, err := NewIndexFromCSVContext(ctx, path, Options{}); !errors.Is(err, context.Canceled) || idx != nil {
+		t.Fatalf("NewIndexFromCSVContext(canceled) = %v, %v; want nil, context.Canceled", idx, err)
+	}
+
+	// una recarga cancelada a mitad conserva los datos publicados
+	idx, err := NewIndexFromCSV(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := idx.DataVersion()
+	const rows = 4*shardRows + 10 // cinco tramos
+	if err := os.WriteFile(path, []byte(syntheticCSV(rows)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cctx := newCancelAfter(3)
+	if err := idx.ReloadContext(cctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReloadContext = %v, want context.Canceled", err)
+	}
+	if cctx.calls != 3 {
+		t.Errorf("ctx checked %d times, want the build to stop at the 3rd of 5 shards", cctx.calls)
+	}
+	if idx.Len() != 10 || idx.DataVersion() != version {
+		t.Errorf("after canceled reload: Len() = %d, DataVersion changed = %v; want the previous data", idx.Len(), idx.DataVersion() != version)
+	}
+	if _, ok := idx.Lookup("100000009"); !ok {
+		t.Error("previous data no longer served")
+	}
+
+	if err := idx.ReloadContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != rows {
+		t.Errorf("Len() = %d after reload, want %d", idx.Len(), rows)
+	}
+}
@@ -0,0 +1,245 @@
+package rnc
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+/* ---------- Índice de nombres ---------- */
+
+// Fold pasa s a minúsculas y le quita los acentos y diacríticos (NFD sin
+// marcas combinantes), para comparar nombres como los escribe la gente:
+// "PEÑA", "Pena" y "peña" dan "pena"; "JOSÉ" da "jose".
+func Fold(s string) string {
+	if isASCII(s) {
+		return strings.ToLower(s)
+	}
+	// transform.Chain no es seguro para uso concurrente: uno por llamada
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	out, _, err := transform.String(t, s)
+	if err != nil {
+		out = s
+	}
+	return strings.ToLower(out)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// nameIndex guarda los nombres pasados por Fold concatenados en un solo
+// buffer ("social\x00comercial\n" por empresa) para buscar subcadenas con
+// strings.Index en vez de recorrer el mapa y convertir cada nombre.
+type nameIndex struct {
+	buf     strings.Builder
+	hay     string
+	offsets []int    // inicio de cada empresa en hay
+	rncs    []string // RNC de cada empresa, mismo orden que offsets
+
+	// byPrefix son los nombres (subcadenas de hay) ordenados, para
+	// autocompletar con búsqueda binaria.
+	byPrefix []namePrefix
+}
+
+// namePrefix es un nombre social o comercial, hay[start:end], y la empresa
+// a la que pertenece (posición en offsets). Con posiciones en lugar de un
+// string ocupa la mitad.
+type namePrefix struct {
+	start, end uint32
+	pos        int32
+}
+
+// name devuelve el nombre de p.
+func (n *nameIndex) name(p namePrefix) string {
+	return n.hay[p.start:p.end]
+}
+
+func newNameIndex(n int) *nameIndex {
+	return &nameIndex{
+		offsets: make([]int, 0, n),
+		rncs:    make([]string, 0, n),
+	}
+}
+
+func (n *nameIndex) add(e Empresa) {
+	n.addFolded(e.RNC, Fold(e.SocialName), Fold(e.ComercialName))
+}
+
+// addFolded es como add con los nombres ya pasados por Fold.
+func (n *nameIndex) addFolded(rnc, social, comercial string) {
+	n.offsets = append(n.offsets, n.buf.Len())
+	n.rncs = append(n.rncs, rnc)
+	n.buf.WriteString(social)
+	n.buf.WriteByte(0)
+	n.buf.WriteString(comercial)
+	n.buf.WriteByte('\n')
+}
+
+// dropSuperseded quita las entradas de los RNC que una fila posterior del
+// CSV reemplazó, para que quede una por empresa, con los nombres de la
+// última fila y en su posición. Se llama antes de finish, solo si hubo
+// duplicados.
+func (n *nameIndex) dropSuperseded() {
+	keep := make([]bool, len(n.rncs))
+	seen := make(map[string]bool, len(n.rncs))
+	for i := len(n.rncs) - 1; i >= 0; i-- {
+		if !seen[n.rncs[i]] {
+			seen[n.rncs[i]], keep[i] = true, true
+		}
+	}
+	hay := n.buf.String()
+	var buf strings.Builder
+	buf.Grow(len(hay))
+	offsets, rncs := n.offsets[:0], n.rncs[:0]
+	for i, start := range n.offsets {
+		end := len(hay)
+		if i+1 < len(n.offsets) {
+			end = n.offsets[i+1]
+		}
+		if keep[i] {
+			offsets = append(offsets, buf.Len())
+			rncs = append(rncs, n.rncs[i])
+			buf.WriteString(hay[start:end])
+		}
+	}
+	n.buf, n.offsets, n.rncs = buf, offsets, rncs
+}
+
+// finish congela el buffer; se llama una vez al terminar buildIndex.
+func (n *nameIndex) finish() {
+	n.hay = n.buf.String()
+	n.buf = strings.Builder{}
+	n.sortPrefixes()
+}
+
+// nameIndexFrom reconstruye un nameIndex terminado a partir de hay, el
+// buffer de otro (ver LoadCache), y los RNC en el mismo orden, sin volver a
+// pasar los nombres por Fold. ok es false si no corresponden.
+func nameIndexFrom(hay string, rncs []string) (n *nameIndex, ok bool) {
+	n = &nameIndex{hay: hay, rncs: rncs, offsets: make([]int, 0, len(rncs))}
+	for start := 0; start < len(hay); {
+		end := strings.IndexByte(hay[start:], '\n')
+		if end < 0 || len(n.offsets) == len(rncs) {
+			return nil, false
+		}
+		n.offsets = append(n.offsets, start)
+		start += end + 1
+	}
+	if len(n.offsets) != len(rncs) {
+		return nil, false
+	}
+	n.sortPrefixes()
+	return n, true
+}
+
+// sortPrefixes arma byPrefix a partir de hay.
+func (n *nameIndex) sortPrefixes() {
+	n.byPrefix = make([]namePrefix, 0, 2*len(n.offsets))
+	for i, start := range n.offsets {
+		end := len(n.hay)
+		if i+1 < len(n.offsets) {
+			end = n.offsets[i+1]
+		}
+		sep := start + strings.IndexByte(n.hay[start:end], 0)
+		social, comercial := n.hay[start:sep], n.hay[sep+1:end-1]
+		n.byPrefix = append(n.byPrefix, namePrefix{uint32(start), uint32(sep), int32(i)})
+		if comercial != social && comercial != "" {
+			n.byPrefix = append(n.byPrefix, namePrefix{uint32(sep + 1), uint32(end - 1), int32(i)})
+		}
+	}
+	slices.SortFunc(n.byPrefix, func(a, b namePrefix) int { return strings.Compare(n.name(a), n.name(b)) })
+}
+
+// MinSuggestLen es el largo mínimo de una consulta de autocompletado; las
+// más cortas coincidirían con demasiados nombres.
+const MinSuggestLen = 3
+
+// suggest devuelve los RNC de hasta limit empresas con algún nombre que
+// empieza por q (pasado por Fold), los nombres más cortos primero.
+func (n *nameIndex) suggest(q string, limit int) []string {
+	q = Fold(strings.TrimSpace(q))
+	if n == nil || utf8.RuneCountInString(q) < MinSuggestLen || limit <= 0 {
+		return []string{}
+	}
+	less := func(a, b namePrefix) bool {
+		an, bn := n.name(a), n.name(b)
+		return len(an) < len(bn) || (len(an) == len(bn) && an < bn)
+	}
+	// best se mantiene ordenado y con una entrada por empresa
+	best := make([]namePrefix, 0, limit)
+	i, _ := slices.BinarySearchFunc(n.byPrefix, q, func(p namePrefix, q string) int { return strings.Compare(n.name(p), q) })
+	for ; i < len(n.byPrefix) && strings.HasPrefix(n.name(n.byPrefix[i]), q); i++ {
+		c := n.byPrefix[i]
+		if dup := slices.IndexFunc(best, func(b namePrefix) bool { return b.pos == c.pos }); dup >= 0 {
+			if !less(c, best[dup]) {
+				continue
+			}
+			best = slices.Delete(best, dup, dup+1)
+		}
+		if len(best) == limit && !less(c, best[limit-1]) {
+			continue
+		}
+		at, _ := slices.BinarySearchFunc(best, c, func(a, b namePrefix) int {
+			if less(a, b) {
+				return -1
+			}
+			return 1
+		})
+		best = slices.Insert(best, at, c)
+		if len(best) > limit {
+			best = best[:limit]
+		}
+	}
+	out := make([]string, len(best))
+	for j, b := range best {
+		out[j] = n.rncs[b.pos]
+	}
+	return out
+}
+
+// search devuelve los RNC de la página pedida y el total de empresas que
+// contienen q (sin distinguir mayúsculas ni acentos) en alguno de sus
+// nombres.
+func (n *nameIndex) search(q string, limit, offset int) ([]string, int) {
+	if n == nil {
+		return nil, 0
+	}
+	q = Fold(q)
+	if q == "" || strings.ContainsAny(q, "\x00\n") {
+		return nil, 0
+	}
+	hay := n.hay
+	var keys []string
+	total := 0
+	for pos := 0; pos < len(hay); {
+		i := strings.Index(hay[pos:], q)
+		if i < 0 {
+			break
+		}
+		// Empresa que contiene la coincidencia: último offset <= pos+i
+		e := sort.Search(len(n.offsets), func(j int) bool { return n.offsets[j] > pos+i }) - 1
+		if total >= offset && len(keys) < limit {
+			keys = append(keys, n.rncs[e])
+		}
+		total++
+		// Saltar a la siguiente empresa para contarla una sola vez
+		if e+1 < len(n.offsets) {
+			pos = n.offsets[e+1]
+		} else {
+			break
+		}
+	}
+	return keys, total
+}
@@ -0,0 +1,115 @@
+package rnc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"FERRETERIA", "ferreteria"},
+		{"PEÑA", "pena"},
+		{"Peña", "pena"},
+		{"JOSÉ ÁLVAREZ", "jose alvarez"},
+		{"Ü", "u"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestSearchAccents comprueba que la búsqueda ignora mayúsculas y acentos en
+// ambos sentidos y que devuelve los nombres tal como vienen en el CSV.
+func TestSearchAccents(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"132138279,FERRETERÍA PEÑA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101010632,CONSTRUCTORA JOSE SA,Construcciones José,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n")
+
+	tests := []struct {
+		q, want string
+	}{
+		{"ferreteria pena", "FERRETERÍA PEÑA SRL"},
+		{"FERRETERÍA PEÑA", "FERRETERÍA PEÑA SRL"},
+		{"josé", "CONSTRUCTORA JOSE SA"},
+		{"construcciones jose", "CONSTRUCTORA JOSE SA"},
+	}
+	for _, tt := range tests {
+		res, total := idx.Search(tt.q, 10, 0)
+		if total != 1 || len(res) != 1 || res[0].SocialName != tt.want {
+			t.Errorf("Search(%q) = %+v (total %d), want %s", tt.q, res, total, tt.want)
+		}
+	}
+}
+
+// TestSuggestNames comprueba el orden (nombres más cortos primero), que cada
+// empresa sale una vez aunque coincidan sus dos nombres, y el largo mínimo.
+func TestSuggestNames(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101010632,FERRETERÍA PEÑA SA,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"131000012,ACME SRL,FERRE,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+
+	tests := []struct {
+		q     string
+		limit int
+		want  []string
+	}{
+		{"ferre", 10, []string{"131000012", "132138279", "101010632"}},
+		{"FERRE", 2, []string{"131000012", "132138279"}},
+		{"ferreteria", 10, []string{"101010632", "132138279"}},
+		{"acm", 10, []string{"131000012"}},
+		{"fe", 10, nil},
+		{"ferre", 0, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, emp := range idx.SuggestNames(tt.q, tt.limit) {
+			got = append(got, emp.RNC)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("SuggestNames(%q, %d) = %v, want %v", tt.q, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// TestNameIndexFrom comprueba que el índice de nombres reconstruido desde el
+// buffer de otro responde igual y que un buffer que no corresponde a los RNC
+// se rechaza.
+func TestNameIndexFrom(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n")
+	orig := idx.data.Load().byName
+
+	n, ok := nameIndexFrom(orig.hay, orig.rncs)
+	if !ok {
+		t.Fatal("nameIndexFrom rejected the buffer of a built index")
+	}
+	for _, q := range []string{"ferreteria", "caribe", "ferretodo", "no existe"} {
+		want, wantTotal := orig.search(q, 10, 0)
+		if got, total := n.search(q, 10, 0); !slices.Equal(got, want) || total != wantTotal {
+			t.Errorf("search(%q) = %v (%d), want %v (%d)", q, got, total, want, wantTotal)
+		}
+	}
+	if got, want := n.suggest("ferre", 10), orig.suggest("ferre", 10); !slices.Equal(got, want) {
+		t.Errorf("suggest = %v, want %v", got, want)
+	}
+
+	for name, rncs := range map[string][]string{
+		"menos RNC": orig.rncs[:1],
+		"más RNC":   append(slices.Clone(orig.rncs), "131000012"),
+	} {
+		if _, ok := nameIndexFrom(orig.hay, rncs); ok {
+			t.Errorf("%s: nameIndexFrom accepted a buffer that does not match", name)
+		}
+	}
+	if _, ok := nameIndexFrom(strings.TrimSuffix(orig.hay, "\n"), orig.rncs); ok {
+		t.Error("nameIndexFrom accepted a buffer without the last newline")
+	}
+}
@@ -0,0 +1,73 @@
+package rnc
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// openCSV abre el padrón en path. Los .zip (primer miembro .csv) y .gz se
+// descomprimen al vuelo; cualquier otra extensión se lee tal cual.
+func openCSV(path string) (io.ReadCloser, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".zip":
+		return openZipCSV(path)
+	case ".gz":
+		return openGzip(path)
+	}
+	return os.Open(path)
+}
+
+// multiCloser cierra el lector descomprimido y luego el archivo que lo alimenta.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m multiCloser) Close() error {
+	var errs []error
+	for _, c := range m.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// openZipCSV abre el primer miembro .csv del ZIP en path, como lo publica
+// la DGII.
+func openZipCSV(path string) (io.ReadCloser, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening ZIP: %w", err)
+	}
+	for _, f := range zr.File {
+		if !strings.HasSuffix(strings.ToLower(f.Name), ".csv") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			zr.Close()
+			return nil, fmt.Errorf("error reading %s from ZIP: %w", f.Name, err)
+		}
+		return multiCloser{rc, []io.Closer{rc, zr}}, nil
+	}
+	zr.Close()
+	return nil, errors.New("CSV file not found in ZIP")
+}
+
+func openGzip(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error opening gzip: %w", err)
+	}
+	return multiCloser{gz, []io.Closer{gz, f}}, nil
+}
@@ -0,0 +1,48 @@
+package rnc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestIndexCompressed comprueba que NewIndexFromCSV lee el padrón desde un
+// .zip, un .gz o un .csv, según la extensión.
+func TestIndexCompressed(t *testing.T) {
+	csv := testHeader + "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(csv))
+	zw.Close()
+
+	files := map[string][]byte{
+		"rncs.csv":    []byte(csv),
+		"rncs.csv.gz": gz.Bytes(),
+		"RNC.ZIP":     testZIP(t, "TMP/RNC_CONTRIBUYENTES.csv", csv),
+	}
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			idx, err := NewIndexFromCSV(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if emp, ok := idx.Lookup("132138279"); !ok || emp.SocialName != "FERRETERIA AMERICANA SRL" {
+				t.Errorf("Lookup = %+v, %v", emp, ok)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "vacio.zip")
+	if err := os.WriteFile(path, testZIP(t, "LEEME.txt", "nada"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIndexFromCSV(path); err == nil {
+		t.Error("a ZIP without a .csv member was accepted")
+	}
+}
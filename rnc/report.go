@@ -0,0 +1,57 @@
+package rnc
+
+import (
+	"context"
+	"io"
+)
+
+/* ---------- Revisión de un CSV ---------- */
+
+// CSVReport resume un CSV leído con ValidateCSV.
+type CSVReport struct {
+	Header     bool `json:"header"`     // la primera fila es una cabecera reconocida
+	Rows       int  `json:"rows"`       // filas de datos, sin contar la cabecera
+	Entries    int  `json:"entries"`    // RNC distintos, lo que tendría el índice
+	Skipped    int  `json:"skipped"`    // filas descartadas por tener pocas columnas
+	Duplicates int  `json:"duplicates"` // filas cuyo RNC ya apareció antes (gana la última)
+}
+
+// ValidateCSV lee el CSV de path como lo haría NewIndexFromCSVOptions con
+// opts (solo importa el formato: Delimiter, HeaderRows y Encoding), sin
+// construir el índice, y cuenta sus filas. Devuelve error si el archivo no se
+// puede leer o interpretar; un CSV sin entradas no es un error, el llamador
+// decide con el informe.
+func ValidateCSV(path string, opts Options) (CSVReport, error) {
+	opts = opts.format().options()
+	v := &validator{seen: make(map[string]struct{})}
+	if err := readCSV(context.Background(), func() (io.ReadCloser, error) { return openCSV(path) }, opts, v); err != nil {
+		return CSVReport{}, err
+	}
+	return v.report, nil
+}
+
+// validator es el rowSink de ValidateCSV: solo guarda los RNC vistos.
+type validator struct {
+	report CSVReport
+	seen   map[string]struct{}
+}
+
+func (v *validator) header([]string) { v.report.Header = true }
+
+func (v *validator) skipped(n int) {
+	v.report.Skipped = n
+	v.report.Rows += n
+}
+
+func (v *validator) add(rows []parsedRow) error {
+	for _, p := range rows {
+		v.report.Rows++
+		if _, dup := v.seen[p.emp.RNC]; dup {
+			v.report.Duplicates++
+			continue
+		}
+		v.seen[p.emp.RNC] = struct{}{}
+		v.report.Entries++
+	}
+	return nil
+}
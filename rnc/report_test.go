@@ -0,0 +1,39 @@
+package rnc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateCSV(t *testing.T) {
+	const row = "132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	tests := []struct {
+		name, csv string
+		want      CSVReport
+	}{
+		{"limpio", testHeader + row + "101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n",
+			CSVReport{Header: true, Rows: 2, Entries: 2}},
+		{"fila corta", testHeader + row + "101010632\n",
+			CSVReport{Header: true, Rows: 2, Entries: 1, Skipped: 1}},
+		{"RNC repetido", testHeader + row + "1-32-13827-9,OTRO NOMBRE,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n",
+			CSVReport{Header: true, Rows: 2, Entries: 1, Duplicates: 1}},
+		{"sin cabecera", row, CSVReport{Rows: 1, Entries: 1}},
+		{"vacío", testHeader, CSVReport{Header: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rncs.csv")
+			if err := os.WriteFile(path, []byte(tt.csv), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ValidateCSV(path, Options{})
+			if err != nil || got != tt.want {
+				t.Errorf("ValidateCSV = %+v, %v; want %+v", got, err, tt.want)
+			}
+		})
+	}
+	if _, err := ValidateCSV(filepath.Join(t.TempDir(), "missing.csv"), Options{}); err == nil {
+		t.Error("ValidateCSV of a missing file succeeded")
+	}
+}
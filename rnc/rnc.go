@@ -0,0 +1,50 @@
+// Package rnc contiene el núcleo de rncs: lectura del padrón de la DGII,
+// índice en memoria para búsquedas por RNC y por nombre, validación del
+// dígito verificador y descarga del archivo oficial.
+//
+// Los tipos exportados no dependen de estado global, por lo que varios
+// índices pueden convivir en un mismo proceso.
+package rnc
+
+// Empresa es el registro de un contribuyente tal como lo expone la API.
+type Empresa struct {
+	RNC              string `json:"rnc"`
+	SocialName       string `json:"socialName"`
+	ComercialName    string `json:"comercialName"`
+	Status           string `json:"status"`
+	EconomicActivity string `json:"economicActivity,omitempty"`
+	PaymentRegime    string `json:"paymentRegime,omitempty"`
+	Category         string `json:"category,omitempty"`
+
+	// Extra son las columnas pedidas con Options.Fields, por nombre.
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// empresaRaw es una fila del CSV con los nombres de columna de la DGII.
+type empresaRaw struct {
+	RNC                string
+	RazonSocial        string
+	NombreComercial    string
+	Categoria          string
+	RegimenPago        string
+	Estado             string
+	ActividadEconomica string
+}
+
+func mapToAPI(e empresaRaw) Empresa {
+	comercial := e.NombreComercial
+	if comercial == "" {
+		comercial = e.RazonSocial // fallback cuando la columna viene vacía
+	}
+	// la clave del índice pasa por la misma normalización que las consultas
+	rnc, _ := Normalize(e.RNC)
+	return Empresa{
+		RNC:              rnc,
+		SocialName:       e.RazonSocial,
+		ComercialName:    comercial,
+		Status:           e.Estado,
+		EconomicActivity: e.ActividadEconomica,
+		PaymentRegime:    e.RegimenPago,
+		Category:         e.Categoria,
+	}
+}
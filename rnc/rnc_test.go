@@ -0,0 +1,48 @@
+package rnc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestComercialName(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,ACTIVO,NORMAL\n"+
+		"131000012,JOSÉ PEÑA SRL,  ,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101000122,OMEGA SRL,OMEGA SRL,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+
+	tests := []struct {
+		name      string
+		rnc       string
+		social    string
+		comercial string
+	}{
+		{"nombres distintos", "132138279", "FERRETERIA AMERICANA SRL", "FERRETODO"},
+		{"comercial vacío", "101010632", "CONSTRUCTORA DEL CARIBE SA", "CONSTRUCTORA DEL CARIBE SA"},
+		{"comercial en blanco", "131000012", "JOSÉ PEÑA SRL", "JOSÉ PEÑA SRL"},
+		{"nombres iguales", "101000122", "OMEGA SRL", "OMEGA SRL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emp, ok := idx.Lookup(tt.rnc)
+			if !ok {
+				t.Fatalf("Lookup(%s) not found", tt.rnc)
+			}
+			b, err := json.Marshal(emp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got struct {
+				SocialName    string `json:"socialName"`
+				ComercialName string `json:"comercialName"`
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.SocialName != tt.social || got.ComercialName != tt.comercial {
+				t.Errorf("socialName = %q, comercialName = %q; want %q, %q", got.SocialName, got.ComercialName, tt.social, tt.comercial)
+			}
+		})
+	}
+}
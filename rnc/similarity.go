@@ -0,0 +1,99 @@
+package rnc
+
+import (
+	"strings"
+	"unicode"
+)
+
+/* ---------- Similitud de nombres ---------- */
+
+// legalForms son las formas societarias que se ignoran al comparar nombres:
+// "ACME SRL" y "Acme, S.R.L." son la misma empresa.
+var legalForms = map[string]bool{
+	"srl": true, "sa": true, "sas": true, "eirl": true, "cxa": true,
+	"inc": true, "ltd": true, "ltda": true, "sl": true,
+}
+
+// NameSimilarity compara dos nombres de empresa y devuelve un puntaje entre 0
+// y 1: Jaro-Winkler sobre los nombres sin mayúsculas, acentos, puntuación ni
+// forma societaria. Pensado para verificar que el nombre de un comprobante
+// corresponde a la razón social registrada del RNC.
+func NameSimilarity(a, b string) float64 {
+	return jaroWinkler(comparableName(a), comparableName(b))
+}
+
+// comparableName pasa s por Fold, quita los puntos ("s.r.l." -> "srl"),
+// cambia el resto de la puntuación por espacios y descarta las formas
+// societarias, salvo que el nombre no tenga otra cosa.
+func comparableName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '.':
+			return -1
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return r
+		}
+		return ' '
+	}, Fold(s))
+	words := strings.Fields(s)
+	kept := words[:0:0]
+	for _, w := range words {
+		if !legalForms[w] {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == 0 {
+		kept = words
+	}
+	return strings.Join(kept, " ")
+}
+
+// jaroWinkler es la similitud de Jaro con la bonificación de Winkler por
+// prefijo común (hasta 4 caracteres, factor 0.1).
+func jaroWinkler(a, b string) float64 {
+	s, t := []rune(a), []rune(b)
+	if len(s) == 0 && len(t) == 0 {
+		return 1
+	}
+	if len(s) == 0 || len(t) == 0 {
+		return 0
+	}
+	window := max(0, max(len(s), len(t))/2-1)
+	sMatched := make([]bool, len(s))
+	tMatched := make([]bool, len(t))
+	matches := 0
+	for i := range s {
+		for j := max(0, i-window); j < min(len(t), i+window+1); j++ {
+			if !tMatched[j] && s[i] == t[j] {
+				sMatched[i], tMatched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	// transposiciones: coincidencias que aparecen en distinto orden
+	transpositions, j := 0, 0
+	for i := range s {
+		if !sMatched[i] {
+			continue
+		}
+		for !tMatched[j] {
+			j++
+		}
+		if s[i] != t[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(s)) + m/float64(len(t)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(s), len(t)) && s[prefix] == t[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
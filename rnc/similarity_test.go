@@ -0,0 +1,40 @@
+package rnc
+
+import "testing"
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"ACME SRL", "Acme, S.R.L.", 1, 1},
+		{"FERRETERÍA PEÑA", "ferreteria pena sa", 1, 1},
+		{"FERRETERIA AMERICANA SRL", "FERRETERIA AMERICNA", 0.9, 0.99},
+		{"FERRETERIA AMERICANA SRL", "CONSTRUCTORA DEL CARIBE SA", 0, 0.6},
+		{"SRL", "S.R.L.", 1, 1}, // solo forma societaria: no se descarta
+		{"", "ACME", 0, 0},
+	}
+	for _, tt := range tests {
+		if got := NameSimilarity(tt.a, tt.b); got < tt.min || got > tt.max {
+			t.Errorf("NameSimilarity(%q, %q) = %.3f, want in [%g, %g]", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+// TestJaroWinkler usa los ejemplos clásicos de la literatura.
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"martha", "marhta", 0.961},
+		{"dixon", "dicksonx", 0.813},
+		{"abc", "abc", 1},
+		{"abc", "xyz", 0},
+	}
+	for _, tt := range tests {
+		if got := jaroWinkler(tt.a, tt.b); got < tt.want-0.001 || got > tt.want+0.001 {
+			t.Errorf("jaroWinkler(%q, %q) = %.3f, want %.3f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package rnc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // driver "sqlite", sin CGO
+)
+
+/* ---------- Índice en disco (SQLite) ---------- */
+
+// sqliteFormat cambia cuando cambia el esquema o el contenido de la base
+// (2: reparación de UTF-8).
+const sqliteFormat = 2
+
+const sqliteSchema = `
+CREATE TABLE empresas (
+	rnc               TEXT PRIMARY KEY,
+	social_name       TEXT NOT NULL,
+	comercial_name    TEXT NOT NULL,
+	status            TEXT NOT NULL,
+	economic_activity TEXT NOT NULL,
+	payment_regime    TEXT NOT NULL,
+	category          TEXT NOT NULL
+) WITHOUT ROWID;
+CREATE TABLE meta (meta TEXT NOT NULL);`
+
+const sqliteColumns = `rnc, social_name, comercial_name, status, economic_activity, payment_regime, category`
+
+// SQLiteStore es como BoltStore pero guarda el padrón en una base SQLite
+// (modernc.org/sqlite, sin CGO), consultada por la clave primaria rnc. Es
+// seguro para uso concurrente.
+type SQLiteStore struct {
+	csvPath, dbPath string
+	opts            Options // solo el formato del CSV (csvFormat)
+
+	mu       sync.RWMutex // Replace espera a las lecturas antes de cerrar la base vieja
+	db       *sql.DB
+	meta     diskMeta
+	loadedAt time.Time
+}
+
+// OpenSQLite abre la base dbPath si se generó a partir de la versión actual
+// de csvPath; si no existe o está desactualizada la reconstruye desde el CSV,
+// sin cargarlo en memoria.
+func OpenSQLite(csvPath, dbPath string) (*SQLiteStore, error) {
+	return OpenSQLiteOptions(csvPath, dbPath, Options{})
+}
+
+// OpenSQLiteOptions es como OpenSQLite para un CSV que se lee con opts; como
+// en OpenBoltOptions, solo se usa el formato del CSV.
+func OpenSQLiteOptions(csvPath, dbPath string, opts Options) (*SQLiteStore, error) {
+	st, err := os.Stat(csvPath)
+	if err != nil {
+		return nil, err
+	}
+	opts = opts.format().options()
+	s := &SQLiteStore{csvPath: csvPath, dbPath: dbPath, opts: opts}
+	db, meta, err := openSQLiteDB(dbPath)
+	if err == nil && meta.matches(sqliteFormat, st, opts) {
+		slog.Info("Index opened from disk", "path", dbPath, "entries", meta.Entries)
+		s.db, s.meta, s.loadedAt = db, meta, time.Now()
+		return s, nil
+	}
+	if db != nil {
+		db.Close()
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Rebuilding on-disk index", "path", dbPath, "err", err)
+	}
+	if err := s.rebuild(context.Background(), csvPath, 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload vuelve a construir la base desde el CSV de origen. Si falla, la base
+// anterior sigue en uso.
+func (s *SQLiteStore) Reload() error {
+	return s.rebuild(context.Background(), s.csvPath, 0)
+}
+
+// ReloadContext es Reload con un ctx que interrumpe la construcción.
+func (s *SQLiteStore) ReloadContext(ctx context.Context) error {
+	return s.rebuild(ctx, s.csvPath, 0)
+}
+
+// Replace es como BoltStore.Replace.
+func (s *SQLiteStore) Replace(path string, minEntries int) error {
+	return s.rebuild(context.Background(), path, minEntries)
+}
+
+// ReplaceContext es Replace con un ctx que interrumpe la construcción; si se
+// cancela, la base y el CSV actuales no se tocan.
+func (s *SQLiteStore) ReplaceContext(ctx context.Context, path string, minEntries int) error {
+	return s.rebuild(ctx, path, minEntries)
+}
+
+// rebuild construye la base desde path en un archivo temporal y la pone en
+// lugar de dbPath (y path en lugar de csvPath, si son distintos).
+func (s *SQLiteStore) rebuild(ctx context.Context, path string, minEntries int) error {
+	src, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, meta, err := buildSQLite(ctx, path, filepath.Dir(s.dbPath), src, s.opts)
+	if err != nil {
+		return fmt.Errorf("error parsing new CSV: %w", err)
+	}
+	defer os.Remove(tmp)
+	if meta.Entries < minEntries {
+		return fmt.Errorf("%w: %d, expected at least %d", ErrTooFewEntries, meta.Entries, minEntries)
+	}
+	if path != s.csvPath {
+		if err := os.Rename(path, s.csvPath); err != nil {
+			return err
+		}
+	}
+	// las conexiones abiertas siguen leyendo el archivo viejo
+	if err := os.Rename(tmp, s.dbPath); err != nil {
+		return err
+	}
+	db, meta, err := openSQLiteDB(s.dbPath)
+	if err != nil {
+		if db != nil {
+			db.Close()
+		}
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.db
+	s.db, s.meta, s.loadedAt = db, meta, time.Now()
+	s.mu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	slog.Info("Index written to disk", "path", s.dbPath, "entries", meta.Entries)
+	return nil
+}
+
+// Lookup busca un contribuyente por RNC o cédula, con las mismas tolerancias
+// de formato que Index.Lookup.
+func (s *SQLiteStore) Lookup(rnc string) (Empresa, bool) {
+	norm, ok := Normalize(rnc)
+	if !ok {
+		return Empresa{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range variants(norm) {
+		var e Empresa
+		err := s.db.QueryRow(`SELECT `+sqliteColumns+` FROM empresas WHERE rnc = ?`, k).Scan(
+			&e.RNC, &e.SocialName, &e.ComercialName, &e.Status, &e.EconomicActivity, &e.PaymentRegime, &e.Category)
+		switch {
+		case err == nil:
+			return e, true
+		case !errors.Is(err, sql.ErrNoRows):
+			slog.Error("On-disk index lookup failed", "rnc", rnc, "err", err)
+			return Empresa{}, false
+		}
+	}
+	return Empresa{}, false
+}
+
+// Len devuelve el número de entradas de la base.
+func (s *SQLiteStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Entries
+}
+
+// LoadedAt devuelve el momento en que se abrió la base actual.
+func (s *SQLiteStore) LoadedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loadedAt
+}
+
+// SourceInfo devuelve la fecha de modificación y el tamaño del CSV del que
+// salió la base actual.
+func (s *SQLiteStore) SourceInfo() (modTime time.Time, size int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.CSVModTime, s.meta.CSVSize
+}
+
+// DataVersion identifica el contenido de la base; coincide con el de un
+// Index cargado del mismo CSV.
+func (s *SQLiteStore) DataVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Version
+}
+
+// Duplicates devuelve cuántas filas del CSV repetían un RNC anterior.
+func (s *SQLiteStore) Duplicates() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.meta.Duplicates
+}
+
+// Close cierra la base.
+func (s *SQLiteStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Close()
+}
+
+// openSQLiteDB abre dbPath en solo lectura y lee sus metadatos. La base no
+// cambia una vez escrita (Replace la sustituye por otra), así que se abre
+// como immutable, sin bloqueos.
+func openSQLiteDB(dbPath string) (*sql.DB, diskMeta, error) {
+	var meta diskMeta
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, meta, err
+	}
+	db, err := sql.Open("sqlite", sqliteDSN(dbPath, "mode=ro&immutable=1"))
+	if err != nil {
+		return nil, meta, err
+	}
+	var v string
+	if err := db.QueryRow(`SELECT meta FROM meta`).Scan(&v); err != nil {
+		return db, meta, fmt.Errorf("on-disk index is incomplete: %w", err)
+	}
+	return db, meta, json.Unmarshal([]byte(v), &meta)
+}
+
+func sqliteDSN(path, query string) string {
+	return (&url.URL{Scheme: "file", OmitHost: true, Path: path, RawQuery: query}).String()
+}
+
+// buildSQLite escribe el CSV de path en una base nueva dentro de dir y
+// devuelve su ruta. El llamador la mueve a su sitio o la borra.
+func buildSQLite(ctx context.Context, path, dir string, src os.FileInfo, opts Options) (string, diskMeta, error) {
+	b := newSQLiteBuilder(dir)
+	defer b.close()
+	if err := readCSV(ctx, func() (io.ReadCloser, error) { return openCSV(path) }, opts, b); err != nil {
+		os.Remove(b.path)
+		return "", diskMeta{}, err
+	}
+	meta, err := b.finish(src, opts)
+	if err != nil {
+		os.Remove(b.path)
+		return "", diskMeta{}, err
+	}
+	if meta.Duplicates > 0 {
+		slog.Warn("CSV has duplicate RNCs, keeping the last row of each", "duplicates", meta.Duplicates)
+	}
+	return b.path, meta, nil
+}
+
+// sqliteBuilder es el rowSink que escribe una base nueva, un tramo por
+// transacción.
+type sqliteBuilder struct {
+	path string
+	db   *sql.DB
+	err  error // si no se pudo crear la base; add lo devuelve
+	rows int   // filas escritas, con repetidas; las entradas salen de COUNT(*)
+}
+
+func newSQLiteBuilder(dir string) *sqliteBuilder {
+	b := &sqliteBuilder{}
+	f, err := os.CreateTemp(dir, "rncs-*.sqlite.tmp")
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.path = f.Name()
+	f.Close()
+	// sin diario ni fsync: si algo falla el archivo temporal se descarta, y
+	// finish sincroniza una vez al terminar
+	dsn := sqliteDSN(b.path, "_pragma=journal_mode(OFF)&_pragma=synchronous(OFF)&_pragma=cache_size(-65536)")
+	if b.db, b.err = sql.Open("sqlite", dsn); b.err != nil {
+		return b
+	}
+	b.db.SetMaxOpenConns(1) // los pragmas son por conexión
+	_, b.err = b.db.Exec(sqliteSchema)
+	return b
+}
+
+func (b *sqliteBuilder) header([]string) {}
+
+func (b *sqliteBuilder) skipped(int) {}
+
+func (b *sqliteBuilder) add(rows []parsedRow) error {
+	if b.err != nil {
+		return b.err
+	}
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	args := make([]any, 0, sqliteBatch*7)
+	for batch := range slices.Chunk(rows, sqliteBatch) {
+		args = args[:0]
+		for _, p := range batch {
+			e := p.emp
+			args = append(args, e.RNC, e.SocialName, e.ComercialName, e.Status, e.EconomicActivity, e.PaymentRegime, e.Category)
+		}
+		if _, err := tx.Exec(sqliteInsert(len(batch)), args...); err != nil {
+			return err
+		}
+	}
+	b.rows += len(rows)
+	return tx.Commit()
+}
+
+// sqliteBatch es cuántas filas escribe cada INSERT: una sentencia por fila
+// hace que construir la base lleve varias veces más que leer el CSV.
+const sqliteBatch = 128
+
+// sqliteInsert devuelve un INSERT de n filas.
+func sqliteInsert(n int) string {
+	return `INSERT OR REPLACE INTO empresas (` + sqliteColumns + `) VALUES ` +
+		strings.Repeat(`(?, ?, ?, ?, ?, ?, ?), `, n-1) + `(?, ?, ?, ?, ?, ?, ?)`
+}
+
+// finish calcula la versión de los datos (en orden de RNC, como Index),
+// guarda los metadatos y sincroniza la base.
+func (b *sqliteBuilder) finish(src os.FileInfo, opts Options) (diskMeta, error) {
+	meta := diskMeta{Format: sqliteFormat, CSVModTime: src.ModTime(), CSVSize: src.Size(), CSV: opts.format()}
+	err := b.writeMeta(&meta)
+	if err == nil {
+		_, err = b.db.Exec(`PRAGMA synchronous = FULL; VACUUM`) // VACUUM compacta y sincroniza
+	}
+	if cerr := b.close(); err == nil {
+		err = cerr
+	}
+	return meta, err
+}
+
+func (b *sqliteBuilder) writeMeta(meta *diskMeta) error {
+	rows, err := b.db.Query(`SELECT ` + sqliteColumns + ` FROM empresas ORDER BY rnc`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	h := fnv.New64a()
+	for rows.Next() {
+		var e Empresa
+		if err := rows.Scan(&e.RNC, &e.SocialName, &e.ComercialName, &e.Status, &e.EconomicActivity, &e.PaymentRegime, &e.Category); err != nil {
+			return err
+		}
+		hashEmpresa(h, e)
+		meta.Entries++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	meta.Duplicates = b.rows - meta.Entries
+	meta.Version = strconv.FormatUint(h.Sum64(), 16)
+	v, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	_, err = b.db.Exec(`INSERT INTO meta (meta) VALUES (?)`, string(v))
+	return err
+}
+
+func (b *sqliteBuilder) close() error {
+	if b.db == nil {
+		return nil
+	}
+	db := b.db
+	b.db = nil
+	return db.Close()
+}
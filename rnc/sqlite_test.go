@@ -0,0 +1,71 @@
+package rnc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestSQLiteStore comprueba que la base SQLite responde como un Index del
+// mismo CSV, que se reutiliza mientras el CSV no cambie y que Replace cambia
+// de base solo si el CSV nuevo tiene las entradas pedidas.
+func TestSQLiteStore(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "rncs.csv")
+	dbPath := filepath.Join(dir, "rncs.sqlite")
+	const one = "132138279,FERRETERIA AMERICANA SRL,FERRETODO,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	const rows = one + "00113918205,JUAN PEREZ,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n" +
+		"1-32-13827-9,FERRETERIA AMERICANA SA,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"
+	if err := os.WriteFile(csvPath, []byte(testHeader+rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenSQLite(csvPath, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := newTestIndex(t, rows)
+	for _, q := range []string{"1-32-13827-9", "001-1391820-5", "0113918205"} {
+		got, ok := s.Lookup(q)
+		want, _ := idx.Lookup(q)
+		if !ok || !reflect.DeepEqual(got, want) {
+			t.Errorf("Lookup(%q) = %+v, %v; want %+v", q, got, ok, want)
+		}
+	}
+	if _, ok := s.Lookup("131000012"); ok {
+		t.Error("Lookup(131000012) found a record")
+	}
+	if s.Len() != 2 || s.Duplicates() != 1 || s.DataVersion() != idx.DataVersion() {
+		t.Errorf("Len = %d, Duplicates = %d, DataVersion = %s; want 2, 1 and %s", s.Len(), s.Duplicates(), s.DataVersion(), idx.DataVersion())
+	}
+	s.Close()
+
+	// el CSV no cambió: se abre la base existente sin reconstruirla
+	before, _ := os.Stat(dbPath)
+	s, err = OpenSQLite(csvPath, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { s.Close() }()
+	if after, _ := os.Stat(dbPath); !after.ModTime().Equal(before.ModTime()) {
+		t.Error("database rebuilt although the CSV did not change")
+	}
+
+	small := filepath.Join(dir, "new.csv")
+	if err := os.WriteFile(small, []byte(testHeader+one), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Replace(small, 2); err == nil {
+		t.Error("Replace accepted a CSV with fewer entries than required")
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len after a rejected Replace = %d, want 2", s.Len())
+	}
+	if err := s.Replace(small, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Lookup("00113918205"); ok || s.Len() != 1 {
+		t.Errorf("after Replace: Len = %d, old RNC found = %v; want 1 and false", s.Len(), ok)
+	}
+}
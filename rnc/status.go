@@ -0,0 +1,92 @@
+package rnc
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+/* ---------- Índice por estado ---------- */
+
+// StatusCount es un estado del padrón y cuántos contribuyentes lo tienen.
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// statusIndex agrupa las empresas por estado normalizado (sin mayúsculas ni
+// acentos). Guarda posiciones en byName.rncs, que mantienen el orden del CSV.
+type statusIndex struct {
+	byStatus map[string][]int32
+	counts   []StatusCount // con la grafía más frecuente de cada estado
+}
+
+func newStatusIndex(t *tables) *statusIndex {
+	si := &statusIndex{byStatus: make(map[string][]int32)}
+	spellings := make(map[string]map[string]int)
+	for i, k := range t.byName.rncs {
+		status := t.dict.vals[t.byRNC[k].status]
+		key := foldStatus(status)
+		si.byStatus[key] = append(si.byStatus[key], int32(i))
+		if spellings[key] == nil {
+			spellings[key] = make(map[string]int)
+		}
+		spellings[key][status]++
+	}
+	for key, forms := range spellings {
+		var best string
+		for s, n := range forms {
+			if n > forms[best] || (n == forms[best] && s < best) {
+				best = s
+			}
+		}
+		si.counts = append(si.counts, StatusCount{Status: best, Count: len(si.byStatus[key])})
+	}
+	slices.SortFunc(si.counts, func(a, b StatusCount) int {
+		return cmp.Or(b.Count-a.Count, cmp.Compare(a.Status, b.Status))
+	})
+	return si
+}
+
+// foldStatus compara estados sin distinguir mayúsculas ni acentos
+// ("Activo" = "ACTIVO").
+func foldStatus(s string) string {
+	return normalizeHeader(s)
+}
+
+// ByStatus devuelve, en el orden del CSV, la página [offset, offset+limit)
+// de empresas con el estado status (sin distinguir mayúsculas ni acentos) y
+// el total de coincidencias.
+func (x *Index) ByStatus(status string, limit, offset int) ([]Empresa, int) {
+	s := x.data.Load()
+	pos := s.statuses.byStatus[foldStatus(status)]
+	if offset >= len(pos) {
+		return []Empresa{}, len(pos)
+	}
+	page := pos[offset:min(len(pos), offset+limit)]
+	out := make([]Empresa, 0, len(page))
+	for _, i := range page {
+		out = append(out, s.empresa(s.byName.rncs[i]))
+	}
+	return out, len(pos)
+}
+
+// WithStatus es como All pero solo con las empresas del estado status (sin
+// distinguir mayúsculas ni acentos).
+func (x *Index) WithStatus(status string) iter.Seq[Empresa] {
+	s := x.data.Load()
+	pos := s.statuses.byStatus[foldStatus(status)]
+	return func(yield func(Empresa) bool) {
+		for _, i := range pos {
+			if !yield(s.empresa(s.byName.rncs[i])) {
+				return
+			}
+		}
+	}
+}
+
+// Statuses devuelve los estados distintos con su número de contribuyentes,
+// de más a menos frecuente.
+func (x *Index) Statuses() []StatusCount {
+	return slices.Clone(x.data.Load().statuses.counts)
+}
@@ -0,0 +1,44 @@
+package rnc
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestByStatus comprueba que el estado se compara sin mayúsculas ni acentos,
+// que las páginas siguen el orden del CSV y que Statuses usa la grafía más
+// frecuente.
+func TestByStatus(t *testing.T) {
+	idx := newTestIndex(t, ""+
+		"132138279,FERRETERIA AMERICANA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n"+
+		"101010632,CONSTRUCTORA DEL CARIBE SA,,CONSTRUCCION,01/01/2000,SUSPENDIDO,NORMAL\n"+
+		"131000012,ACME SRL,,COMERCIO,01/01/2000,Activo,NORMAL\n"+
+		"101000122,OMEGA SRL,,COMERCIO,01/01/2000,ACTIVO,NORMAL\n")
+
+	tests := []struct {
+		status        string
+		limit, offset int
+		want          []string
+		total         int
+	}{
+		{"ACTIVO", 10, 0, []string{"132138279", "131000012", "101000122"}, 3},
+		{"actívo", 2, 1, []string{"131000012", "101000122"}, 3},
+		{"ACTIVO", 10, 5, nil, 3},
+		{"DADO DE BAJA", 10, 0, nil, 0},
+	}
+	for _, tt := range tests {
+		res, total := idx.ByStatus(tt.status, tt.limit, tt.offset)
+		var got []string
+		for _, emp := range res {
+			got = append(got, emp.RNC)
+		}
+		if !slices.Equal(got, tt.want) || total != tt.total {
+			t.Errorf("ByStatus(%q, %d, %d) = %v (total %d), want %v (total %d)", tt.status, tt.limit, tt.offset, got, total, tt.want, tt.total)
+		}
+	}
+
+	want := []StatusCount{{"ACTIVO", 3}, {"SUSPENDIDO", 1}}
+	if got := idx.Statuses(); !slices.Equal(got, want) {
+		t.Errorf("Statuses = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package rnc
+
+import "time"
+
+// Store es lo que necesita una consulta por RNC. Lo cumplen Index, con todo
+// el padrón en memoria, y BoltStore y SQLiteStore, que lo leen de disco; las
+// funciones que solo buscan por RNC deberían recibir un Store para poder
+// cambiar el backend (o usar uno de prueba).
+type Store interface {
+	// Lookup busca un contribuyente por RNC o cédula, con las mismas
+	// tolerancias de formato que Index.Lookup.
+	Lookup(rnc string) (Empresa, bool)
+	// Len devuelve el número de entradas.
+	Len() int
+	// LoadedAt devuelve el momento en que se cargaron los datos actuales.
+	LoadedAt() time.Time
+	// SourceInfo devuelve la fecha y el tamaño del CSV del que salieron.
+	SourceInfo() (modTime time.Time, size int64)
+	// DataVersion identifica el contenido de los datos actuales.
+	DataVersion() string
+	// Duplicates devuelve cuántas filas del CSV repetían un RNC anterior.
+	Duplicates() int
+	// Reload vuelve a leer el CSV de origen; si falla, los datos anteriores
+	// siguen en uso.
+	Reload() error
+}
+
+var (
+	_ Store = (*Index)(nil)
+	_ Store = (*BoltStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
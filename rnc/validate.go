@@ -0,0 +1,100 @@
+package rnc
+
+import "strings"
+
+// Normalize quita guiones, puntos y espacios de un RNC o cédula tal como se
+// imprimen en facturas ("1-32-13827-9", "001-1391820-5"). ok es false si el
+// resultado contiene algo distinto de dígitos.
+func Normalize(s string) (norm string, ok bool) {
+	norm = strings.Map(func(r rune) rune {
+		switch r {
+		case '-', '.', ' ', '\t', '\n', '\r':
+			return -1
+		}
+		return r
+	}, s)
+	return norm, isDigits(norm)
+}
+
+// variants devuelve las formas en que un RNC normalizado puede estar
+// guardado en el padrón: tal cual y con o sin el cero inicial de las formas
+// de 9 (RNC) y 11 (cédula) dígitos.
+func variants(s string) []string {
+	out := []string{s}
+	switch {
+	case len(s) == 8 || len(s) == 10:
+		out = append(out, "0"+s)
+	case (len(s) == 9 || len(s) == 11) && s[0] == '0':
+		out = append(out, s[1:])
+	}
+	return out
+}
+
+// Tipos de documento reconocidos por Validate.
+const (
+	TypeRNC    = "rnc"
+	TypeCedula = "cedula"
+)
+
+// Validation es el resultado de validar un RNC o cédula sin consultar el
+// padrón.
+type Validation struct {
+	Valid  bool   `json:"valid"`
+	Type   string `json:"type,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Validate comprueba el formato y el dígito verificador de un RNC (9 dígitos,
+// módulo 11) o de una cédula (11 dígitos, Luhn). Acepta guiones y espacios.
+func Validate(number string) Validation {
+	norm, ok := Normalize(number)
+	if !ok {
+		return Validation{Reason: "must contain only digits"}
+	}
+	switch len(norm) {
+	case 9:
+		if digitoRNC(norm[:8]) != int(norm[8]-'0') {
+			return Validation{Type: TypeRNC, Reason: "bad check digit"}
+		}
+		return Validation{Valid: true, Type: TypeRNC}
+	case 11:
+		if digitoCedula(norm[:10]) != int(norm[10]-'0') {
+			return Validation{Type: TypeCedula, Reason: "bad check digit"}
+		}
+		return Validation{Valid: true, Type: TypeCedula}
+	}
+	return Validation{Reason: "wrong length, expected 9 (RNC) or 11 (cedula) digits"}
+}
+
+// Valid indica si number es un RNC o cédula con dígito verificador correcto.
+func Valid(number string) bool {
+	return Validate(number).Valid
+}
+
+func digitoRNC(base string) int {
+	pesos := [8]int{7, 9, 8, 6, 5, 4, 3, 2}
+	suma := 0
+	for i, p := range pesos {
+		suma += int(base[i]-'0') * p
+	}
+	switch resto := suma % 11; resto {
+	case 0:
+		return 2
+	case 1:
+		return 1
+	default:
+		return 11 - resto
+	}
+}
+
+func digitoCedula(base string) int {
+	suma := 0
+	for i := 0; i < len(base); i++ {
+		d := int(base[i]-'0') * (1 + i%2)
+		if d > 9 {
+			d -= 9
+		}
+		suma += d
+	}
+	return (10 - suma%10) % 10
+}
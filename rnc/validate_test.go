@@ -0,0 +1,55 @@
+package rnc
+
+import "testing"
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		rnc  string
+		want bool
+	}{
+		// RNC: 9 dígitos, módulo 11
+		{"132138279", true},
+		{"101010632", true},
+		{"132138278", false},
+		{"101010631", false},
+
+		// cédula: 11 dígitos, Luhn
+		{"00113918205", true},
+		{"40200000004", true},
+		{"00113918204", false},
+		{"40200000005", false},
+
+		// largo o caracteres incorrectos
+		{"13213827", false},
+		{"1321382790", false},
+		{"001139182051", false},
+		{"", false},
+		{"13213827X", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.rnc); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.rnc, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, norm string
+		ok       bool
+	}{
+		{"132138279", "132138279", true},
+		{"1-32-13827-9", "132138279", true},
+		{"001-1391820-5", "00113918205", true},
+		{"001.1391820.5", "00113918205", true},
+		{"\t132 138 279\r\n", "132138279", true},
+		{"", "", false},
+		{"13213827a", "13213827a", false},
+	}
+	for _, tt := range tests {
+		norm, ok := Normalize(tt.in)
+		if norm != tt.norm || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, %v", tt.in, norm, ok, tt.norm, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package rnc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+/* ---------- Verificación RNC + nombre ---------- */
+
+// Valores de verifyResult.Result.
+const (
+	verifyMatch    = "match"     // el RNC existe y el nombre corresponde
+	verifyMismatch = "mismatch"  // el RNC existe pero el nombre no corresponde
+	verifyNotFound = "not-found" // el RNC no está en el padrón (o es inválido)
+)
+
+type verifyRequest struct {
+	RNC  string `json:"rnc"`
+	Name string `json:"name"`
+}
+
+// verifyResult compara el nombre recibido con la razón social y el nombre
+// comercial registrados; Score es el mejor de los dos.
+type verifyResult struct {
+	RNC           string  `json:"rnc"`
+	Name          string  `json:"name"`
+	Result        string  `json:"result"`
+	Match         bool    `json:"match"`
+	Score         float64 `json:"score"`
+	SocialName    string  `json:"socialName,omitempty"`
+	ComercialName string  `json:"comercialName,omitempty"`
+}
+
+// verificar compara req con el registro de su RNC en idx; el nombre
+// corresponde si la similitud llega a threshold.
+func verificar(idx Store, req verifyRequest, threshold float64) verifyResult {
+	res := verifyResult{RNC: req.RNC, Name: req.Name, Result: verifyNotFound}
+	emp, ok := idx.Lookup(req.RNC)
+	if !ok {
+		return res
+	}
+	res.SocialName, res.ComercialName = emp.SocialName, emp.ComercialName
+	res.Score = NameSimilarity(req.Name, emp.SocialName)
+	if emp.ComercialName != "" {
+		res.Score = max(res.Score, NameSimilarity(req.Name, emp.ComercialName))
+	}
+	res.Score = math.Round(res.Score*1000) / 1000
+	res.Match = res.Score >= threshold
+	res.Result = verifyMismatch
+	if res.Match {
+		res.Result = verifyMatch
+	}
+	return res
+}
+
+// verify atiende POST /api/verify con {"rnc":"...","name":"..."} o un
+// arreglo de hasta maxBatchSize de ellos; responde con un resultado o un
+// arreglo de resultados, en el mismo orden.
+func (h *handler) verify(w http.ResponseWriter, r *http.Request) {
+	if !AllowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var body json.RawMessage
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody)).Decode(&body); err != nil {
+		WriteError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	batch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+	var reqs []verifyRequest
+	if batch {
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON body")
+			return
+		}
+		if len(reqs) > maxBatchSize {
+			WriteError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Too many items (max %d)", maxBatchSize))
+			return
+		}
+	} else {
+		var req verifyRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			WriteError(w, http.StatusBadRequest, "Invalid JSON body")
+			return
+		}
+		reqs = []verifyRequest{req}
+	}
+	for i, req := range reqs {
+		if strings.TrimSpace(req.RNC) == "" || strings.TrimSpace(req.Name) == "" {
+			msg := "rnc and name are required"
+			if batch {
+				msg = fmt.Sprintf("item %d: %s", i, msg)
+			}
+			WriteError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	idx, ok := h.current(w, r)
+	if !ok {
+		return
+	}
+
+	results := make([]verifyResult, len(reqs))
+	for i, req := range reqs {
+		results[i] = verificar(idx, req, h.verifyThreshold)
+	}
+	if !batch {
+		h.countLookup("/api/verify", results[0].Result != verifyNotFound)
+		WriteJSON(w, http.StatusOK, results[0])
+		return
+	}
+	WriteJSON(w, http.StatusOK, results)
+}
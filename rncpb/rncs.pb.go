@@ -0,0 +1,487 @@
+// Servicio gRPC de rncs (--grpc-port). El código Go está en rncpb/ y se
+// regenera con:
+//
+//   protoc --go_out=. --go_opt=module=github.com/yolfry/rncs \
+//     --go-grpc_out=. --go-grpc_opt=module=github.com/yolfry/rncs \
+//     proto/rncs.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: proto/rncs.proto
+
+package rncpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Empresa es el registro de un contribuyente, como en la API HTTP.
+type Empresa struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Rnc              string                 `protobuf:"bytes,1,opt,name=rnc,proto3" json:"rnc,omitempty"`
+	SocialName       string                 `protobuf:"bytes,2,opt,name=social_name,json=socialName,proto3" json:"social_name,omitempty"`
+	ComercialName    string                 `protobuf:"bytes,3,opt,name=comercial_name,json=comercialName,proto3" json:"comercial_name,omitempty"`
+	Status           string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	EconomicActivity string                 `protobuf:"bytes,5,opt,name=economic_activity,json=economicActivity,proto3" json:"economic_activity,omitempty"`
+	PaymentRegime    string                 `protobuf:"bytes,6,opt,name=payment_regime,json=paymentRegime,proto3" json:"payment_regime,omitempty"`
+	Category         string                 `protobuf:"bytes,7,opt,name=category,proto3" json:"category,omitempty"`
+	// columnas pedidas con --fields, por nombre
+	Extra         map[string]string `protobuf:"bytes,8,rep,name=extra,proto3" json:"extra,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empresa) Reset() {
+	*x = Empresa{}
+	mi := &file_proto_rncs_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empresa) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empresa) ProtoMessage() {}
+
+func (x *Empresa) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_rncs_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empresa.ProtoReflect.Descriptor instead.
+func (*Empresa) Descriptor() ([]byte, []int) {
+	return file_proto_rncs_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Empresa) GetRnc() string {
+	if x != nil {
+		return x.Rnc
+	}
+	return ""
+}
+
+func (x *Empresa) GetSocialName() string {
+	if x != nil {
+		return x.SocialName
+	}
+	return ""
+}
+
+func (x *Empresa) GetComercialName() string {
+	if x != nil {
+		return x.ComercialName
+	}
+	return ""
+}
+
+func (x *Empresa) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Empresa) GetEconomicActivity() string {
+	if x != nil {
+		return x.EconomicActivity
+	}
+	return ""
+}
+
+func (x *Empresa) GetPaymentRegime() string {
+	if x != nil {
+		return x.PaymentRegime
+	}
+	return ""
+}
+
+func (x *Empresa) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Empresa) GetExtra() map[string]string {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+type CheckRNCRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rnc           string                 `protobuf:"bytes,1,opt,name=rnc,proto3" json:"rnc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRNCRequest) Reset() {
+	*x = CheckRNCRequest{}
+	mi := &file_proto_rncs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRNCRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRNCRequest) ProtoMessage() {}
+
+func (x *CheckRNCRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_rncs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRNCRequest.ProtoReflect.Descriptor instead.
+func (*CheckRNCRequest) Descriptor() ([]byte, []int) {
+	return file_proto_rncs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckRNCRequest) GetRnc() string {
+	if x != nil {
+		return x.Rnc
+	}
+	return ""
+}
+
+type SearchByNameRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"` // 20 por defecto, máximo 100
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchByNameRequest) Reset() {
+	*x = SearchByNameRequest{}
+	mi := &file_proto_rncs_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchByNameRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchByNameRequest) ProtoMessage() {}
+
+func (x *SearchByNameRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_rncs_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchByNameRequest.ProtoReflect.Descriptor instead.
+func (*SearchByNameRequest) Descriptor() ([]byte, []int) {
+	return file_proto_rncs_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SearchByNameRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchByNameRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchByNameRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type SearchByNameResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Results       []*Empresa             `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchByNameResponse) Reset() {
+	*x = SearchByNameResponse{}
+	mi := &file_proto_rncs_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchByNameResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchByNameResponse) ProtoMessage() {}
+
+func (x *SearchByNameResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_rncs_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchByNameResponse.ProtoReflect.Descriptor instead.
+func (*SearchByNameResponse) Descriptor() ([]byte, []int) {
+	return file_proto_rncs_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchByNameResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchByNameResponse) GetResults() []*Empresa {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type BatchCheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rncs          []string               `protobuf:"bytes,1,rep,name=rncs,proto3" json:"rncs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCheckRequest) Reset() {
+	*x = BatchCheckRequest{}
+	mi := &file_proto_rncs_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCheckRequest) ProtoMessage() {}
+
+func (x *BatchCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_rncs_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCheckRequest.ProtoReflect.Descriptor instead.
+func (*BatchCheckRequest) Descriptor() ([]byte, []int) {
+	return file_proto_rncs_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchCheckRequest) GetRncs() []string {
+	if x != nil {
+		return x.Rncs
+	}
+	return nil
+}
+
+type BatchCheckResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Results []*Empresa             `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	// los RNC pedidos que no existen, en el orden recibido
+	NotFound      []string `protobuf:"bytes,2,rep,name=not_found,json=notFound,proto3" json:"not_found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCheckResponse) Reset() {
+	*x = BatchCheckResponse{}
+	mi := &file_proto_rncs_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCheckResponse) ProtoMessage() {}
+
+func (x *BatchCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_rncs_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCheckResponse.ProtoReflect.Descriptor instead.
+func (*BatchCheckResponse) Descriptor() ([]byte, []int) {
+	return file_proto_rncs_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchCheckResponse) GetResults() []*Empresa {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BatchCheckResponse) GetNotFound() []string {
+	if x != nil {
+		return x.NotFound
+	}
+	return nil
+}
+
+var File_proto_rncs_proto protoreflect.FileDescriptor
+
+const file_proto_rncs_proto_rawDesc = "" +
+	"\n" +
+	"\x10proto/rncs.proto\x12\arncs.v1\"\xd8\x02\n" +
+	"\aEmpresa\x12\x10\n" +
+	"\x03rnc\x18\x01 \x01(\tR\x03rnc\x12\x1f\n" +
+	"\vsocial_name\x18\x02 \x01(\tR\n" +
+	"socialName\x12%\n" +
+	"\x0ecomercial_name\x18\x03 \x01(\tR\rcomercialName\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12+\n" +
+	"\x11economic_activity\x18\x05 \x01(\tR\x10economicActivity\x12%\n" +
+	"\x0epayment_regime\x18\x06 \x01(\tR\rpaymentRegime\x12\x1a\n" +
+	"\bcategory\x18\a \x01(\tR\bcategory\x121\n" +
+	"\x05extra\x18\b \x03(\v2\x1b.rncs.v1.Empresa.ExtraEntryR\x05extra\x1a8\n" +
+	"\n" +
+	"ExtraEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"#\n" +
+	"\x0fCheckRNCRequest\x12\x10\n" +
+	"\x03rnc\x18\x01 \x01(\tR\x03rnc\"Y\n" +
+	"\x13SearchByNameRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"X\n" +
+	"\x14SearchByNameResponse\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12*\n" +
+	"\aresults\x18\x02 \x03(\v2\x10.rncs.v1.EmpresaR\aresults\"'\n" +
+	"\x11BatchCheckRequest\x12\x12\n" +
+	"\x04rncs\x18\x01 \x03(\tR\x04rncs\"]\n" +
+	"\x12BatchCheckResponse\x12*\n" +
+	"\aresults\x18\x01 \x03(\v2\x10.rncs.v1.EmpresaR\aresults\x12\x1b\n" +
+	"\tnot_found\x18\x02 \x03(\tR\bnotFound2\xd8\x01\n" +
+	"\n" +
+	"RNCService\x126\n" +
+	"\bCheckRNC\x12\x18.rncs.v1.CheckRNCRequest\x1a\x10.rncs.v1.Empresa\x12K\n" +
+	"\fSearchByName\x12\x1c.rncs.v1.SearchByNameRequest\x1a\x1d.rncs.v1.SearchByNameResponse\x12E\n" +
+	"\n" +
+	"BatchCheck\x12\x1a.rncs.v1.BatchCheckRequest\x1a\x1b.rncs.v1.BatchCheckResponseB$Z\"github.com/yolfry/rncs/rncpb;rncpbb\x06proto3"
+
+var (
+	file_proto_rncs_proto_rawDescOnce sync.Once
+	file_proto_rncs_proto_rawDescData []byte
+)
+
+func file_proto_rncs_proto_rawDescGZIP() []byte {
+	file_proto_rncs_proto_rawDescOnce.Do(func() {
+		file_proto_rncs_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_rncs_proto_rawDesc), len(file_proto_rncs_proto_rawDesc)))
+	})
+	return file_proto_rncs_proto_rawDescData
+}
+
+var file_proto_rncs_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_proto_rncs_proto_goTypes = []any{
+	(*Empresa)(nil),              // 0: rncs.v1.Empresa
+	(*CheckRNCRequest)(nil),      // 1: rncs.v1.CheckRNCRequest
+	(*SearchByNameRequest)(nil),  // 2: rncs.v1.SearchByNameRequest
+	(*SearchByNameResponse)(nil), // 3: rncs.v1.SearchByNameResponse
+	(*BatchCheckRequest)(nil),    // 4: rncs.v1.BatchCheckRequest
+	(*BatchCheckResponse)(nil),   // 5: rncs.v1.BatchCheckResponse
+	nil,                          // 6: rncs.v1.Empresa.ExtraEntry
+}
+var file_proto_rncs_proto_depIdxs = []int32{
+	6, // 0: rncs.v1.Empresa.extra:type_name -> rncs.v1.Empresa.ExtraEntry
+	0, // 1: rncs.v1.SearchByNameResponse.results:type_name -> rncs.v1.Empresa
+	0, // 2: rncs.v1.BatchCheckResponse.results:type_name -> rncs.v1.Empresa
+	1, // 3: rncs.v1.RNCService.CheckRNC:input_type -> rncs.v1.CheckRNCRequest
+	2, // 4: rncs.v1.RNCService.SearchByName:input_type -> rncs.v1.SearchByNameRequest
+	4, // 5: rncs.v1.RNCService.BatchCheck:input_type -> rncs.v1.BatchCheckRequest
+	0, // 6: rncs.v1.RNCService.CheckRNC:output_type -> rncs.v1.Empresa
+	3, // 7: rncs.v1.RNCService.SearchByName:output_type -> rncs.v1.SearchByNameResponse
+	5, // 8: rncs.v1.RNCService.BatchCheck:output_type -> rncs.v1.BatchCheckResponse
+	6, // [6:9] is the sub-list for method output_type
+	3, // [3:6] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_proto_rncs_proto_init() }
+func file_proto_rncs_proto_init() {
+	if File_proto_rncs_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_rncs_proto_rawDesc), len(file_proto_rncs_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_rncs_proto_goTypes,
+		DependencyIndexes: file_proto_rncs_proto_depIdxs,
+		MessageInfos:      file_proto_rncs_proto_msgTypes,
+	}.Build()
+	File_proto_rncs_proto = out.File
+	file_proto_rncs_proto_goTypes = nil
+	file_proto_rncs_proto_depIdxs = nil
+}
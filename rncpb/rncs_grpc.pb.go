@@ -0,0 +1,218 @@
+// Servicio gRPC de rncs (--grpc-port). El código Go está en rncpb/ y se
+// regenera con:
+//
+//   protoc --go_out=. --go_opt=module=github.com/yolfry/rncs \
+//     --go-grpc_out=. --go-grpc_opt=module=github.com/yolfry/rncs \
+//     proto/rncs.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: proto/rncs.proto
+
+package rncpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RNCService_CheckRNC_FullMethodName     = "/rncs.v1.RNCService/CheckRNC"
+	RNCService_SearchByName_FullMethodName = "/rncs.v1.RNCService/SearchByName"
+	RNCService_BatchCheck_FullMethodName   = "/rncs.v1.RNCService/BatchCheck"
+)
+
+// RNCServiceClient is the client API for RNCService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RNCService consulta el padrón de contribuyentes de la DGII.
+type RNCServiceClient interface {
+	// CheckRNC busca un RNC o cédula. NOT_FOUND si no existe,
+	// INVALID_ARGUMENT si está mal formado o no cumple el dígito verificador.
+	CheckRNC(ctx context.Context, in *CheckRNCRequest, opts ...grpc.CallOption) (*Empresa, error)
+	// SearchByName busca empresas cuyo nombre contiene query (solo con
+	// --backend=memory; si no, UNIMPLEMENTED).
+	SearchByName(ctx context.Context, in *SearchByNameRequest, opts ...grpc.CallOption) (*SearchByNameResponse, error)
+	// BatchCheck resuelve hasta 1000 RNC en una llamada.
+	BatchCheck(ctx context.Context, in *BatchCheckRequest, opts ...grpc.CallOption) (*BatchCheckResponse, error)
+}
+
+type rNCServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRNCServiceClient(cc grpc.ClientConnInterface) RNCServiceClient {
+	return &rNCServiceClient{cc}
+}
+
+func (c *rNCServiceClient) CheckRNC(ctx context.Context, in *CheckRNCRequest, opts ...grpc.CallOption) (*Empresa, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empresa)
+	err := c.cc.Invoke(ctx, RNCService_CheckRNC_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rNCServiceClient) SearchByName(ctx context.Context, in *SearchByNameRequest, opts ...grpc.CallOption) (*SearchByNameResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchByNameResponse)
+	err := c.cc.Invoke(ctx, RNCService_SearchByName_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rNCServiceClient) BatchCheck(ctx context.Context, in *BatchCheckRequest, opts ...grpc.CallOption) (*BatchCheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCheckResponse)
+	err := c.cc.Invoke(ctx, RNCService_BatchCheck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RNCServiceServer is the server API for RNCService service.
+// All implementations must embed UnimplementedRNCServiceServer
+// for forward compatibility.
+//
+// RNCService consulta el padrón de contribuyentes de la DGII.
+type RNCServiceServer interface {
+	// CheckRNC busca un RNC o cédula. NOT_FOUND si no existe,
+	// INVALID_ARGUMENT si está mal formado o no cumple el dígito verificador.
+	CheckRNC(context.Context, *CheckRNCRequest) (*Empresa, error)
+	// SearchByName busca empresas cuyo nombre contiene query (solo con
+	// --backend=memory; si no, UNIMPLEMENTED).
+	SearchByName(context.Context, *SearchByNameRequest) (*SearchByNameResponse, error)
+	// BatchCheck resuelve hasta 1000 RNC en una llamada.
+	BatchCheck(context.Context, *BatchCheckRequest) (*BatchCheckResponse, error)
+	mustEmbedUnimplementedRNCServiceServer()
+}
+
+// UnimplementedRNCServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRNCServiceServer struct{}
+
+func (UnimplementedRNCServiceServer) CheckRNC(context.Context, *CheckRNCRequest) (*Empresa, error) {
+	return nil, status.Error(codes.Unimplemented, "method CheckRNC not implemented")
+}
+func (UnimplementedRNCServiceServer) SearchByName(context.Context, *SearchByNameRequest) (*SearchByNameResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SearchByName not implemented")
+}
+func (UnimplementedRNCServiceServer) BatchCheck(context.Context, *BatchCheckRequest) (*BatchCheckResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchCheck not implemented")
+}
+func (UnimplementedRNCServiceServer) mustEmbedUnimplementedRNCServiceServer() {}
+func (UnimplementedRNCServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeRNCServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RNCServiceServer will
+// result in compilation errors.
+type UnsafeRNCServiceServer interface {
+	mustEmbedUnimplementedRNCServiceServer()
+}
+
+func RegisterRNCServiceServer(s grpc.ServiceRegistrar, srv RNCServiceServer) {
+	// If the following call panics, it indicates UnimplementedRNCServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RNCService_ServiceDesc, srv)
+}
+
+func _RNCService_CheckRNC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRNCRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RNCServiceServer).CheckRNC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RNCService_CheckRNC_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RNCServiceServer).CheckRNC(ctx, req.(*CheckRNCRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RNCService_SearchByName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchByNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RNCServiceServer).SearchByName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RNCService_SearchByName_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RNCServiceServer).SearchByName(ctx, req.(*SearchByNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RNCService_BatchCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RNCServiceServer).BatchCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RNCService_BatchCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RNCServiceServer).BatchCheck(ctx, req.(*BatchCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RNCService_ServiceDesc is the grpc.ServiceDesc for RNCService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RNCService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rncs.v1.RNCService",
+	HandlerType: (*RNCServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CheckRNC",
+			Handler:    _RNCService_CheckRNC_Handler,
+		},
+		{
+			MethodName: "SearchByName",
+			Handler:    _RNCService_SearchByName_Handler,
+		},
+		{
+			MethodName: "BatchCheck",
+			Handler:    _RNCService_BatchCheck_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/rncs.proto",
+}